@@ -0,0 +1,196 @@
+package pdfb
+
+import (
+	"log"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/code39"
+	"github.com/boombuler/barcode/datamatrix"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/boombuler/barcode/qr"
+)
+
+// BarcodeOptions defines options for drawing barcodes
+//
+// Caption prints the encoded data underneath the barcode,
+// Level is the error correction level of QR codes (L, M, Q or H, default M)
+// or the security level of PDF417 barcodes (0-8, default 2)
+type BarcodeOptions struct {
+	Caption bool
+	Level   string
+}
+
+// QRCode is used to draw a QR code of the given size
+func (p *Pdfb) QRCode(data string, x, y, size float64, opts BarcodeOptions) {
+	p.drawBarcode(p.encodeBarcode("qr", data, opts), x, y, size, size, opts)
+
+	p.checkpoint("QR code printed")
+}
+
+// QRCodeInline is used to draw a QR code inline
+func (p *Pdfb) QRCodeInline(data string, size float64, opts BarcodeOptions) {
+	p.barcodeInline("qr", data, size, size, opts)
+}
+
+// Barcode is used to draw a barcode of the given kind, which is one of
+// code128, code39, ean13, upca, datamatrix, pdf417 or qr
+// Use 0 in place of h to keep the aspect ratio of 2D barcodes
+func (p *Pdfb) Barcode(kind, data string, x, y, w, h float64, opts BarcodeOptions) {
+	code := p.encodeBarcode(kind, data, opts)
+
+	if h == 0 {
+		bounds := code.Bounds()
+		h = w * float64(bounds.Dy()) / float64(bounds.Dx())
+	}
+	p.drawBarcode(code, x, y, w, h, opts)
+
+	p.checkpoint("Barcode printed")
+}
+
+// BarcodeInline is used to draw a barcode inline
+func (p *Pdfb) BarcodeInline(kind, data string, w, h float64, opts BarcodeOptions) {
+	p.barcodeInline(kind, data, w, h, opts)
+}
+
+// draws a barcode at the cursor, moving the cursor along in the same way as BoxInline
+func (p *Pdfb) barcodeInline(kind, data string, w, h float64, opts BarcodeOptions) {
+	code := p.encodeBarcode(kind, data, opts)
+	if h == 0 {
+		bounds := code.Bounds()
+		h = w * float64(bounds.Dy()) / float64(bounds.Dx())
+	}
+
+	pageWidth := p.GetPageWidth() - p.margin*2
+	currentX, currentY := p.GetX(), p.GetY()
+	p.drawBarcode(code, currentX, currentY, w, h, opts)
+
+	if opts.Caption {
+		h += p.captionHeight()
+	}
+	if currentX+w < pageWidth {
+		p.SetX(currentX + w)
+	} else {
+		p.SetY(currentY + h)
+		p.SetX(p.margin)
+	}
+
+	p.checkpoint("Inline barcode printed")
+}
+
+// encodes data as a barcode of the given kind
+func (p *Pdfb) encodeBarcode(kind, data string, opts BarcodeOptions) barcode.Barcode {
+	var code barcode.Barcode
+	var err error
+
+	switch strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(kind)) {
+	case "code128":
+		code, err = code128.Encode(data)
+	case "code39":
+		code, err = code39.Encode(data, false, true)
+	case "ean13", "ean":
+		code, err = ean.Encode(data)
+	case "upca", "upc":
+		// UPC-A is EAN-13 with a leading zero
+		code, err = ean.Encode("0" + data)
+	case "datamatrix":
+		code, err = datamatrix.Encode(data)
+	case "pdf417":
+		level := byte(2)
+		if opts.Level != "" {
+			if len(opts.Level) != 1 || opts.Level[0] < '0' || opts.Level[0] > '8' {
+				log.Fatalf("Invalid PDF417 security level (%s)\n", opts.Level)
+			}
+			level = opts.Level[0] - '0'
+		}
+		code, err = pdf417.Encode(data, level)
+	case "qr", "qrcode":
+		var level qr.ErrorCorrectionLevel
+		switch strings.ToUpper(opts.Level) {
+		case "L":
+			level = qr.L
+		case "", "M":
+			level = qr.M
+		case "Q":
+			level = qr.Q
+		case "H":
+			level = qr.H
+		default:
+			log.Fatalf("Invalid QR code error correction level (%s)\n", opts.Level)
+		}
+		code, err = qr.Encode(data, level, qr.Auto)
+	default:
+		log.Fatalf("Invalid barcode kind (%s)\n", kind)
+	}
+
+	if err != nil {
+		log.Fatalf("Barcode could not be encoded (%s)\n", err)
+	}
+
+	return code
+}
+
+// draws the dark modules of a barcode as rectangles in the foreground colour,
+// joining neighbouring modules on the same row into a single rectangle
+func (p *Pdfb) drawBarcode(code barcode.Barcode, x, y, w, h float64, opts BarcodeOptions) {
+	bounds := code.Bounds()
+	rows := bounds.Dy()
+	if code.Metadata().Dimensions == 1 {
+		// 1D barcodes are the same on every row
+		rows = 1
+	}
+	moduleWidth := w / float64(bounds.Dx())
+	moduleHeight := h / float64(rows)
+
+	currentR, currentG, currentB := p.pdf.GetFillColor()
+	p.pdf.SetFillColor(hexToRGB(p.foreground))
+
+	for row := 0; row < rows; row++ {
+		start := -1
+		for col := 0; col <= bounds.Dx(); col++ {
+			dark := col < bounds.Dx() && isDark(code, bounds.Min.X+col, bounds.Min.Y+row)
+			if dark && start < 0 {
+				start = col
+			}
+			if !dark && start >= 0 {
+				p.pdf.Rect(x+float64(start)*moduleWidth, y+float64(row)*moduleHeight, float64(col-start)*moduleWidth, moduleHeight, "F")
+				start = -1
+			}
+		}
+	}
+
+	p.pdf.SetFillColor(currentR, currentG, currentB)
+
+	if opts.Caption {
+		p.drawCaption(code.Content(), x, y+h, w)
+	}
+}
+
+// reports whether a barcode module is dark
+func isDark(code barcode.Barcode, x, y int) bool {
+	r, g, b, _ := code.At(x, y).RGBA()
+	return r+g+b < 0x18000
+}
+
+// returns the height taken up by a barcode caption
+func (p *Pdfb) captionHeight() float64 {
+	return p.lineHeight * 0.75
+}
+
+// draws a small line of text centred under a barcode
+func (p *Pdfb) drawCaption(text string, x, y, w float64) {
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	currentX, currentY := p.GetX(), p.GetY()
+	height := p.captionHeight()
+
+	p.SetFont(Font{Family: p.font.Family, Size: p.font.Size * 0.75})
+	p.pdf.SetXY(x, y)
+	p.cellText(w, height, text, "CM", 0)
+
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+	p.pdf.SetXY(currentX, currentY)
+}
@@ -0,0 +1,514 @@
+package pdfb
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ChartSpec defines a chart drawn with Chart
+//
+// Kind is one of bar, line, area, pie, donut or scatter.
+// Labels name each category of bar, line and area charts and each slice of
+// pie and donut charts, which use the values of the first series.
+// Width defaults to the width between the margins and Height to 80mm.
+// Stacked stacks the series of bar and area charts instead of grouping them.
+type ChartSpec struct {
+	Kind        string
+	Title       string
+	Labels      []string
+	Series      []ChartSeries
+	Width       float64
+	Height      float64
+	Stacked     bool
+	Legend      bool
+	Gridlines   bool
+	ValueLabels bool
+}
+
+// ChartSeries defines a named series of values in a chart
+// Points are used by scatter charts in place of Values.
+// Colour defaults to a colour picked from the accent colour.
+type ChartSeries struct {
+	Name   string
+	Values []float64
+	Points []ChartPoint
+	Colour string
+}
+
+// ChartPoint defines a point in a scatter chart
+type ChartPoint struct {
+	X float64
+	Y float64
+}
+
+// the area of the page a chart is plotted in
+type plotArea struct {
+	x, y, w, h float64
+}
+
+// Chart is used to draw a chart at the cursor, a new page is started
+// if the chart does not fit on the current page
+func (p *Pdfb) Chart(spec ChartSpec) {
+	kind := strings.ToLower(spec.Kind)
+	spec.Kind = kind
+	switch kind {
+	case "bar", "line", "area", "pie", "donut", "scatter":
+	default:
+		log.Fatalf("Invalid chart kind (%s)\n", spec.Kind)
+	}
+	if len(spec.Series) == 0 {
+		log.Fatalln("Charts must have at least one series")
+	}
+
+	left, _, right, _ := p.pdf.GetMargins()
+	_, bottom := p.pdf.GetAutoPageBreak()
+	if spec.Width == 0 {
+		spec.Width = p.GetPageWidth() - left - right
+	}
+	if spec.Height == 0 {
+		spec.Height = 80
+	}
+
+	// start a new page if the chart won't fit
+	if p.GetY()+spec.Height > p.GetPageHeight()-bottom {
+		p.Page()
+	}
+
+	x, y := left, p.GetY()
+
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	currentFillR, currentFillG, currentFillB := p.pdf.GetFillColor()
+	currentDrawR, currentDrawG, currentDrawB := p.pdf.GetDrawColor()
+	currentTextR, currentTextG, currentTextB := p.pdf.GetTextColor()
+	currentWeight := p.pdf.GetLineWidth()
+
+	p.pdf.SetTextColor(hexToRGB(p.foreground))
+
+	// title
+	area := plotArea{x, y, spec.Width, spec.Height}
+	if spec.Title != "" {
+		p.SetFont(Font{Family: p.font.Family, Bold: true})
+		p.pdf.SetXY(x, y)
+		p.cellText(spec.Width, p.lineHeight, spec.Title, "CM", 0)
+		area.y += p.lineHeight
+		area.h -= p.lineHeight
+		p.SetFont(currentFont)
+		p.SetLineHeight(currentLH)
+	}
+
+	// all other text in the chart is smaller
+	p.SetFont(Font{Family: p.font.Family, Size: p.font.Size * 0.75})
+
+	if spec.Legend {
+		area.h -= p.drawChartLegend(spec, kind, area)
+	}
+
+	switch kind {
+	case "pie", "donut":
+		p.drawPieChart(spec, kind == "donut", area)
+	default:
+		p.drawAxisChart(spec, kind, area)
+	}
+
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+	p.pdf.SetFillColor(currentFillR, currentFillG, currentFillB)
+	p.pdf.SetDrawColor(currentDrawR, currentDrawG, currentDrawB)
+	p.pdf.SetTextColor(currentTextR, currentTextG, currentTextB)
+	p.pdf.SetLineWidth(currentWeight)
+
+	// move the cursor below the chart
+	p.pdf.SetXY(left, y+spec.Height)
+	p.Ln(1)
+
+	p.checkpoint("Chart printed")
+}
+
+// returns the colour of the i-th series or slice, the series colour if one is
+// set, otherwise the accent colour with its hue rotated for each i
+func (p *Pdfb) chartColour(spec ChartSpec, i int) (int, int, int) {
+	if i < len(spec.Series) && spec.Series[i].Colour != "" && spec.Kind != "pie" && spec.Kind != "donut" {
+		return hexToRGB(spec.Series[i].Colour)
+	}
+
+	h, s, l := rgbToHSL(hexToRGB(p.accentColour))
+	// rotate by the golden angle so neighbouring colours are far apart
+	h = math.Mod(h+float64(i)*137.508, 360)
+	// keep colours readable if the accent colour is very light or dark
+	l = math.Min(math.Max(l, 0.35), 0.65)
+	return hslToRGB(h, s, l)
+}
+
+// draws a legend along the bottom of the chart area, returning its height
+func (p *Pdfb) drawChartLegend(spec ChartSpec, kind string, area plotArea) float64 {
+	names := []string{}
+	if kind == "pie" || kind == "donut" {
+		names = spec.Labels
+	} else {
+		for _, s := range spec.Series {
+			names = append(names, s.Name)
+		}
+	}
+
+	swatch := p.lineHeight * 0.5
+	gap := p.lineHeight
+
+	// work out the number of rows needed before drawing
+	rows := 1
+	lineWidth := 0.0
+	for _, name := range names {
+		itemWidth := swatch + 1 + p.stringWidth(name) + gap
+		if lineWidth > 0 && lineWidth+itemWidth > area.w {
+			rows++
+			lineWidth = 0
+		}
+		lineWidth += itemWidth
+	}
+	height := float64(rows) * p.lineHeight
+
+	x, y := area.x, area.y+area.h-height
+	for i, name := range names {
+		itemWidth := swatch + 1 + p.stringWidth(name) + gap
+		if x > area.x && x+itemWidth > area.x+area.w {
+			x = area.x
+			y += p.lineHeight
+		}
+		p.pdf.SetFillColor(p.chartColour(spec, i))
+		p.pdf.Rect(x, y+(p.lineHeight-swatch)/2, swatch, swatch, "F")
+		p.pdf.SetXY(x+swatch+1, y)
+		p.cellText(itemWidth-swatch-1, p.lineHeight, name, "LM", 0)
+		x += itemWidth
+	}
+
+	return height
+}
+
+// draws bar, line, area and scatter charts
+func (p *Pdfb) drawAxisChart(spec ChartSpec, kind string, area plotArea) {
+	categories := len(spec.Labels)
+	for _, s := range spec.Series {
+		if len(s.Values) > categories {
+			categories = len(s.Values)
+		}
+	}
+	stacked := spec.Stacked && (kind == "bar" || kind == "area")
+
+	// find the range of the values
+	minY, maxY := 0.0, 0.0
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	if kind == "scatter" {
+		minY, maxY = math.Inf(1), math.Inf(-1)
+		for _, s := range spec.Series {
+			for _, pt := range s.Points {
+				minX, maxX = math.Min(minX, pt.X), math.Max(maxX, pt.X)
+				minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+			}
+		}
+		if math.IsInf(minX, 1) {
+			minX, maxX, minY, maxY = 0, 1, 0, 1
+		}
+	} else if stacked {
+		for c := 0; c < categories; c++ {
+			var pos, neg float64
+			for _, s := range spec.Series {
+				if v := seriesValue(s, c); v > 0 {
+					pos += v
+				} else {
+					neg += v
+				}
+			}
+			minY, maxY = math.Min(minY, neg), math.Max(maxY, pos)
+		}
+	} else {
+		for _, s := range spec.Series {
+			for _, v := range s.Values {
+				minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+			}
+		}
+	}
+	loY, hiY, stepY := niceScale(minY, maxY)
+
+	// leave space for the axis labels, and half a line above for value labels
+	labelWidth := 0.0
+	for v := loY; v <= hiY+stepY/2; v += stepY {
+		labelWidth = math.Max(labelWidth, p.stringWidth(formatChartValue(v)))
+	}
+	plot := plotArea{
+		x: area.x + labelWidth + 2,
+		y: area.y + p.lineHeight/2,
+		w: area.w - labelWidth - 2,
+		h: area.h - p.lineHeight*1.5,
+	}
+	toY := func(v float64) float64 {
+		return plot.y + plot.h - (v-loY)/(hiY-loY)*plot.h
+	}
+
+	// gridlines and y axis labels
+	p.pdf.SetLineWidth(0.1)
+	for v := loY; v <= hiY+stepY/2; v += stepY {
+		if spec.Gridlines {
+			p.pdf.SetDrawColor(221, 221, 221)
+			p.pdf.Line(plot.x, toY(v), plot.x+plot.w, toY(v))
+		}
+		p.pdf.SetXY(area.x, toY(v)-p.lineHeight/2)
+		p.cellText(labelWidth, p.lineHeight, formatChartValue(v), "RM", 0)
+	}
+
+	// x axis labels
+	var toX func(v float64) float64
+	if kind == "scatter" {
+		loX, hiX, stepX := niceScale(minX, maxX)
+		toX = func(v float64) float64 {
+			return plot.x + (v-loX)/(hiX-loX)*plot.w
+		}
+		for v := loX; v <= hiX+stepX/2; v += stepX {
+			if spec.Gridlines {
+				p.pdf.SetDrawColor(221, 221, 221)
+				p.pdf.Line(toX(v), plot.y, toX(v), plot.y+plot.h)
+			}
+			p.pdf.SetXY(toX(v)-stepX, plot.y+plot.h)
+			p.cellText(stepX*2, p.lineHeight, formatChartValue(v), "CM", 0)
+		}
+	} else {
+		categoryWidth := plot.w / float64(categories)
+		for c, label := range spec.Labels {
+			p.pdf.SetXY(plot.x+float64(c)*categoryWidth, plot.y+plot.h)
+			p.cellText(categoryWidth, p.lineHeight, label, "CM", 0)
+		}
+	}
+
+	// axes
+	p.pdf.SetDrawColor(136, 136, 136)
+	p.pdf.SetLineWidth(0.2)
+	p.pdf.Line(plot.x, plot.y, plot.x, plot.y+plot.h)
+	p.pdf.Line(plot.x, toY(0), plot.x+plot.w, toY(0))
+	if loY > 0 || hiY < 0 {
+		p.pdf.Line(plot.x, plot.y+plot.h, plot.x+plot.w, plot.y+plot.h)
+	}
+
+	switch kind {
+	case "bar":
+		p.drawBars(spec, stacked, categories, plot, toY)
+	case "line", "area":
+		p.drawLines(spec, kind == "area", stacked, categories, plot, toY)
+	case "scatter":
+		for i, s := range spec.Series {
+			p.pdf.SetFillColor(p.chartColour(spec, i))
+			for _, pt := range s.Points {
+				p.pdf.Circle(toX(pt.X), toY(pt.Y), 0.8, "F")
+				if spec.ValueLabels {
+					p.drawValueLabel(pt.Y, toX(pt.X), toY(pt.Y)-1)
+				}
+			}
+		}
+	}
+}
+
+// draws grouped or stacked bars
+func (p *Pdfb) drawBars(spec ChartSpec, stacked bool, categories int, plot plotArea, toY func(float64) float64) {
+	categoryWidth := plot.w / float64(categories)
+	groupWidth := categoryWidth * 0.7
+	barWidth := groupWidth / float64(len(spec.Series))
+	if stacked {
+		barWidth = groupWidth
+	}
+
+	for c := 0; c < categories; c++ {
+		var pos, neg float64
+		for i, s := range spec.Series {
+			v := seriesValue(s, c)
+			x := plot.x + float64(c)*categoryWidth + (categoryWidth-groupWidth)/2
+			base := 0.0
+			if stacked {
+				if v > 0 {
+					base, pos = pos, pos+v
+				} else {
+					base, neg = neg, neg+v
+				}
+			} else {
+				x += float64(i) * barWidth
+			}
+
+			top, bottom := toY(base+v), toY(base)
+			if top > bottom {
+				top, bottom = bottom, top
+			}
+			p.pdf.SetFillColor(p.chartColour(spec, i))
+			p.pdf.Rect(x, top, barWidth, bottom-top, "F")
+
+			if spec.ValueLabels && v != 0 {
+				if stacked {
+					p.drawValueLabel(v, x+barWidth/2, (top+bottom)/2+p.lineHeight/4)
+				} else {
+					p.drawValueLabel(v, x+barWidth/2, top-1)
+				}
+			}
+		}
+	}
+}
+
+// draws lines, or filled areas, through the centre of each category
+func (p *Pdfb) drawLines(spec ChartSpec, filled, stacked bool, categories int, plot plotArea, toY func(float64) float64) {
+	categoryWidth := plot.w / float64(categories)
+	toX := func(c int) float64 {
+		return plot.x + (float64(c)+0.5)*categoryWidth
+	}
+
+	currentAlpha, currentBlend := p.pdf.GetAlpha()
+	base := make([]float64, categories)
+
+	for i, s := range spec.Series {
+		values := make([]float64, categories)
+		for c := range values {
+			values[c] = seriesValue(s, c)
+			if stacked {
+				values[c] += base[c]
+			}
+		}
+
+		r, g, b := p.chartColour(spec, i)
+		if filled {
+			points := []gofpdf.PointType{}
+			for c := range values {
+				points = append(points, gofpdf.PointType{X: toX(c), Y: toY(values[c])})
+			}
+			for c := categories - 1; c >= 0; c-- {
+				points = append(points, gofpdf.PointType{X: toX(c), Y: toY(base[c])})
+			}
+			// overlapping areas need to be see-through
+			if !stacked {
+				p.setAlpha(0.5, "")
+			}
+			p.pdf.SetFillColor(r, g, b)
+			p.pdf.Polygon(points, "F")
+			p.pdf.SetAlpha(currentAlpha, currentBlend)
+		}
+
+		p.pdf.SetDrawColor(r, g, b)
+		p.pdf.SetFillColor(r, g, b)
+		p.pdf.SetLineWidth(0.5)
+		for c := 1; c < categories; c++ {
+			p.pdf.Line(toX(c-1), toY(values[c-1]), toX(c), toY(values[c]))
+		}
+		for c := range values {
+			if !filled {
+				p.pdf.Circle(toX(c), toY(values[c]), 0.8, "F")
+			}
+			if spec.ValueLabels {
+				p.drawValueLabel(seriesValue(s, c), toX(c), toY(values[c])-1.5)
+			}
+		}
+
+		if stacked {
+			base = values
+		}
+	}
+}
+
+// draws pie and donut charts using the values of the first series
+func (p *Pdfb) drawPieChart(spec ChartSpec, donut bool, area plotArea) {
+	values := spec.Series[0].Values
+	var total float64
+	for _, v := range values {
+		if v < 0 {
+			log.Fatalf("Pie charts cannot have negative values (%v)\n", v)
+		}
+		total += v
+	}
+	if total == 0 {
+		return
+	}
+
+	radius := math.Min(area.w, area.h) / 2 * 0.9
+	inner := 0.0
+	if donut {
+		inner = radius * 0.55
+	}
+	cx, cy := area.x+area.w/2, area.y+area.h/2
+
+	// slices are separated by lines in the background colour
+	p.pdf.SetDrawColor(hexToRGB(p.background))
+	p.pdf.SetLineWidth(0.3)
+
+	// slices start at 12 o'clock and go clockwise, angles are anticlockwise
+	// from 3 o'clock as they are in gofpdf
+	angle := 90.0
+	for i, v := range values {
+		sweep := v / total * 360
+		start, end := angle-sweep, angle
+
+		p.pdf.SetFillColor(p.chartColour(spec, i))
+		if donut {
+			p.pdf.MoveTo(cx+radius*math.Cos(start*math.Pi/180), cy-radius*math.Sin(start*math.Pi/180))
+			p.arcTo(cx, cy, radius, radius, start, end)
+			p.arcTo(cx, cy, inner, inner, end, start)
+		} else {
+			p.pdf.MoveTo(cx, cy)
+			p.arcTo(cx, cy, radius, radius, start, end)
+		}
+		p.pdf.ClosePath()
+		p.pdf.DrawPath("FD")
+
+		if spec.ValueLabels && v > 0 {
+			mid := (start + end) / 2 * math.Pi / 180
+			labelRadius := (radius + inner) / 2
+			if !donut {
+				labelRadius = radius * 0.65
+			}
+			percent := strconv.FormatFloat(v/total*100, 'f', 0, 64) + "%"
+			p.pdf.SetXY(cx+labelRadius*math.Cos(mid)-radius, cy-labelRadius*math.Sin(mid)-p.lineHeight/2)
+			p.cellText(radius*2, p.lineHeight, percent, "CM", 0)
+		}
+
+		angle = start
+	}
+}
+
+// draws a value centred above the given point
+func (p *Pdfb) drawValueLabel(v, x, y float64) {
+	text := formatChartValue(v)
+	w := p.stringWidth(text)
+	p.pdf.SetXY(x-w/2, y-p.lineHeight)
+	p.cellText(w, p.lineHeight, text, "CB", 0)
+}
+
+// returns the c-th value of a series, or 0 if it has no value there
+func seriesValue(s ChartSeries, c int) float64 {
+	if c < len(s.Values) {
+		return s.Values[c]
+	}
+	return 0
+}
+
+// formats a chart value without trailing zeros
+func formatChartValue(v float64) string {
+	// avoid printing floating point errors such as 0.30000000000000004
+	v = math.Round(v*1e6) / 1e6
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// returns a range covering min to max with steps of 1, 2 or 5 times
+// a power of ten, so that axes have around 5 round numbered ticks
+func niceScale(min, max float64) (lo, hi, step float64) {
+	if min == max {
+		min, max = min-1, max+1
+	}
+	rough := (max - min) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(rough)))
+	switch residual := rough / magnitude; {
+	case residual > 5:
+		step = 10 * magnitude
+	case residual > 2:
+		step = 5 * magnitude
+	case residual > 1:
+		step = 2 * magnitude
+	default:
+		step = magnitude
+	}
+	return math.Floor(min/step) * step, math.Ceil(max/step) * step, step
+}
@@ -0,0 +1,124 @@
+package pdfb
+
+import (
+	"log"
+	"math"
+	"strings"
+)
+
+// ClipRect is used to clip everything drawn inside fn to a rectangle
+func (p *Pdfb) ClipRect(x, y, w, h float64, fn func()) {
+	p.clip(func() {
+		p.rectPath(x, y, w, h)
+	}, fn)
+
+	p.checkpoint("Rectangle clip used")
+}
+
+// ClipCircle is used to clip everything drawn inside fn to a circle centred on x, y
+func (p *Pdfb) ClipCircle(x, y, radius float64, fn func()) {
+	p.clip(func() {
+		p.ellipsePath(x, y, radius, radius)
+	}, fn)
+
+	p.checkpoint("Circle clip used")
+}
+
+// ClipRoundedRect is used to clip everything drawn inside fn to a rectangle
+// with rounded corners, each corner can be given a different radius
+func (p *Pdfb) ClipRoundedRect(x, y, w, h float64, corners Corners, fn func()) {
+	p.clip(func() {
+		p.roundedRectPath(x, y, w, h, corners)
+	}, fn)
+
+	p.checkpoint("Rounded rectangle clip used")
+}
+
+// ClipPolygon is used to clip everything drawn inside fn to a polygon
+func (p *Pdfb) ClipPolygon(points []Point, fn func()) {
+	if len(points) < 3 {
+		log.Fatalf("Polygons must have at least 3 points (%d given)\n", len(points))
+	}
+
+	p.clip(func() {
+		p.polygonPath(points)
+	}, fn)
+
+	p.checkpoint("Polygon clip used")
+}
+
+// ClipText is used to clip everything drawn inside fn to the outline of text
+// written in the current font, with the baseline of the text starting at x, y
+// Eg. filling large heading text with an image or gradient
+func (p *Pdfb) ClipText(x, y float64, text string, fn func()) {
+	auto, bottom := p.pdf.GetAutoPageBreak()
+	p.pdf.SetAutoPageBreak(false, bottom)
+	p.pdf.ClipText(x, y, text, false)
+
+	fn()
+
+	p.pdf.ClipEnd()
+	p.pdf.SetAutoPageBreak(auto, bottom)
+	p.restoreGraphicsState()
+
+	p.checkpoint("Text clip used")
+}
+
+// clips everything drawn inside fn to the outline which path adds to the
+// current path. Automatic page breaks are turned off inside fn, as the clip
+// only applies to the page it was started on.
+func (p *Pdfb) clip(path func(), fn func()) {
+	auto, bottom := p.pdf.GetAutoPageBreak()
+	p.pdf.SetAutoPageBreak(false, bottom)
+	currentX, currentY := p.pdf.GetXY()
+	p.pdf.RawWriteStr("q")
+	path()
+	p.pdf.RawWriteStr("W n")
+	p.pdf.SetXY(currentX, currentY)
+
+	fn()
+
+	p.pdf.RawWriteStr("Q")
+	p.pdf.SetAutoPageBreak(auto, bottom)
+	p.restoreGraphicsState()
+}
+
+// clips an image drawn by draw to the shape given in its options, the
+// image is also clipped to x, y, w, h when crop is true. inset is the
+// distance between the box the shape is sized to and x, y, w, h
+func (p *Pdfb) clipImage(o ImageOptions, crop bool, x, y, w, h, inset float64, draw func()) {
+	if o.Shape == "" && !crop {
+		draw()
+		return
+	}
+	p.clip(p.imageShapePath(o, x-inset, y-inset, w+inset*2, h+inset*2, inset), draw)
+}
+
+// returns a func which adds the shape of an image with the box x, y, w, h
+// to the current path, shrunk by inset on every side
+func (p *Pdfb) imageShapePath(o ImageOptions, x, y, w, h, inset float64) func() {
+	switch strings.ToLower(o.Shape) {
+	case "":
+		return func() {
+			p.rectPath(x+inset, y+inset, w-inset*2, h-inset*2)
+		}
+	case "circle":
+		// the largest circle which fits in the centre of the box
+		radius := math.Min(w, h)/2 - inset
+		return func() {
+			p.ellipsePath(x+w/2, y+h/2, radius, radius)
+		}
+	case "rounded", "roundedrect":
+		radius := o.Radius
+		if radius <= 0 {
+			radius = math.Min(w, h) / 10
+		}
+		radius = math.Max(radius-inset, 0)
+		return func() {
+			p.roundedRectPath(x+inset, y+inset, w-inset*2, h-inset*2, Corners{radius, radius, radius, radius})
+		}
+	default:
+		log.Fatalf("Invalid image shape (%s)\n", o.Shape)
+	}
+	return nil
+}
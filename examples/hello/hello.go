@@ -20,8 +20,8 @@ func main() {
 
 	pdf.Page()
 
-	pdf.Circle(pdf.GetPageWidth(), pdf.GetPageHeight(), 150, "#fff5f5", true, false)
-	pdf.Box(0, 0, pdf.GetPageWidth(), 6, pdf.GetAccentColour(), true, false)
+	pdf.Circle(pdf.GetPageWidth(), pdf.GetPageHeight(), 150, pdfb.Fill{Colour: "#fff5f5"}, pdfb.Stroke{})
+	pdf.Box(0, 0, pdf.GetPageWidth(), 6, pdfb.Fill{Colour: pdf.GetAccentColour()}, pdfb.Stroke{})
 
 	pdf.SetY(80)
 
@@ -35,7 +35,7 @@ func main() {
 	pdf.BoldLn("Here is an example")
 	pdf.SetY(pdf.GetY() + 6)
 
-	pdf.BoxInline(60, 6, pdf.GetAccentColour(), true, false)
+	pdf.BoxInline(60, 6, pdfb.Fill{Colour: pdf.GetAccentColour()}, pdfb.Stroke{})
 
 	pdf.SetFontSize(15)
 	pdf.Ln(6)
@@ -120,7 +120,12 @@ func main() {
 
 	pdf.Image("./fish.png", "c", pdf.GetX(), pdf.GetY(), 0, 70)
 
-	pdf.Ln(1)
+	pdf.Image("./fish.png", "l", pdf.GetX(), pdf.GetY(), 35, 0, pdfb.ImageOptions{
+		Float:   "right",
+		Padding: 2,
+		Border:  pdfb.Stroke{Colour: "#cccccc"},
+		Shape:   "rounded",
+	})
 	pdf.Paragraph("Exercitation mollit veniam velit ex aliquip occaecat commodo Lorem fugiat. Occaecat voluptate Lorem sint consequat consequat incididunt consectetur elit aliqua id. Culpa dolor irure culpa sint cupidatat aliqua sint excepteur laborum. Aliqua ea cupidatat ut irure officia in proident incididunt exercitation anim amet. Ea deserunt ex Lorem consequat labore Lorem deserunt consequat ad aute cupidatat Lorem. Tempor voluptate quis consequat exercitation est ex qui dolore est consectetur est deserunt ut nostrud.")
 
 	//
@@ -138,6 +143,13 @@ func main() {
 		},
 	)
 
+	// faces with other weights are added to the family by weight
+	pdf.ImportFontFamily("RobotoMono", "./RobotoMono",
+		[]pdfb.FontFace{
+			{File: "RobotoMono-Thin.ttf", Weight: 100},
+		},
+	)
+
 	pdf.SetFont(pdfb.Font{Family: "RobotoMono"})
 	pdf.Paragraph("Exercitation mollit veniam velit ex aliquip occaecat commodo Lorem fugiat. Occaecat voluptate Lorem sint consequat consequat incididunt consectetur elit aliqua id. Culpa dolor irure culpa sint cupidatat aliqua sint excepteur laborum. Aliqua ea cupidatat ut irure officia in proident incididunt exercitation anim amet. Ea deserunt ex Lorem consequat labore Lorem deserunt consequat ad aute cupidatat Lorem. Tempor voluptate quis consequat exercitation est ex qui dolore est consectetur est deserunt ut nostrud.")
 
@@ -160,6 +172,11 @@ func main() {
 	pdf.WriteLn("italic strikethrough text.")
 	pdf.SetFont(pdfb.Font{})
 
+	pdf.Write("Here is some ")
+	pdf.SetFont(pdfb.Font{Weight: 100})
+	pdf.WriteLn("thin text.")
+	pdf.SetFont(pdfb.Font{})
+
 	pdf.Ln(1)
 
 	pdf.SetFont(pdfb.Font{Family: "RobotoMono"})
@@ -175,7 +192,7 @@ func main() {
 		},
 	)
 
-	pdf.SetFont(pdfb.Font{Family: "Arial"})
+	pdf.SetFont(pdfb.Font{Family: pdfb.DefaultFont})
 	pdf.Ln(1)
 
 	pdf.Heading(1, "Hyperlinks")
@@ -0,0 +1,97 @@
+package pdfb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/disintegration/gift"
+)
+
+// returns the EXIF orientation of a JPEG, from 1 (the right way up) to 8,
+// or 1 if it doesn't have one
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+
+	// find the APP1 segment holding the EXIF data
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xff {
+		marker := data[i+1]
+		if marker == 0xda {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if end > len(data) {
+			break
+		}
+		if marker == 0xe1 && bytes.HasPrefix(data[i+4:end], []byte("Exif\x00\x00")) {
+			return tiffOrientation(data[i+10 : end])
+		}
+		i = end
+	}
+
+	return 1
+}
+
+// returns the orientation tag of the first IFD of TIFF data, as found in EXIF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// the orientation tag is a single short stored in the entry itself
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// returns the filter which turns an image with the given EXIF orientation
+// the right way up, or nil if it already is
+func orientationFilter(orientation int) gift.Filter {
+	switch orientation {
+	case 2:
+		return gift.FlipHorizontal()
+	case 3:
+		return gift.Rotate180()
+	case 4:
+		return gift.FlipVertical()
+	case 5:
+		return gift.Transpose()
+	case 6:
+		return gift.Rotate270()
+	case 7:
+		return gift.Transverse()
+	case 8:
+		return gift.Rotate90()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package pdfb
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// returns TIFF data with one IFD holding the given tag and short value
+func testTIFF(order binary.ByteOrder, tag, value uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	entry := tiff[10:]
+	order.PutUint16(entry, tag)
+	order.PutUint16(entry[2:], 3)
+	order.PutUint32(entry[4:], 1)
+	order.PutUint16(entry[8:], value)
+	return tiff
+}
+
+// returns a JPEG header with an APP1 segment holding EXIF data
+func testJPEG(tiff []byte) []byte {
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xff, 0xd8, 0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(data[4:], uint16(len(segment)+2))
+	return append(data, segment...)
+}
+
+func TestTiffOrientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		tiff        []byte
+		orientation int
+	}{
+		{"little endian", testTIFF(binary.LittleEndian, 0x0112, 6), 6},
+		{"big endian", testTIFF(binary.BigEndian, 0x0112, 6), 6},
+		{"little endian upside down", testTIFF(binary.LittleEndian, 0x0112, 3), 3},
+		{"big endian mirrored", testTIFF(binary.BigEndian, 0x0112, 2), 2},
+		{"other tag", testTIFF(binary.BigEndian, 0x010f, 6), 1},
+		{"orientation out of range", testTIFF(binary.LittleEndian, 0x0112, 9), 1},
+		{"orientation zero", testTIFF(binary.BigEndian, 0x0112, 0), 1},
+		{"unknown byte order", append([]byte("XX"), testTIFF(binary.BigEndian, 0x0112, 6)[2:]...), 1},
+		{"truncated entry", testTIFF(binary.BigEndian, 0x0112, 6)[:16], 1},
+		{"truncated ifd", testTIFF(binary.LittleEndian, 0x0112, 6)[:9], 1},
+		{"truncated header", []byte("MM\x00*"), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if orientation := tiffOrientation(tt.tiff); orientation != tt.orientation {
+				t.Errorf("tiffOrientation() = %d, want %d", orientation, tt.orientation)
+			}
+		})
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	rotated := testJPEG(testTIFF(binary.BigEndian, 0x0112, 8))
+
+	// an APP0 segment before the EXIF data
+	app0 := append([]byte{0xff, 0xd8, 0xff, 0xe0, 0, 4, 0, 0}, rotated[2:]...)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		orientation int
+	}{
+		{"exif", rotated, 8},
+		{"little endian exif", testJPEG(testTIFF(binary.LittleEndian, 0x0112, 5)), 5},
+		{"after another segment", app0, 8},
+		{"truncated segment", rotated[:len(rotated)-4], 1},
+		{"truncated marker", rotated[:3], 1},
+		{"not a jpeg", append([]byte("\x89PNG"), rotated[2:]...), 1},
+		{"start of scan before exif", append([]byte{0xff, 0xd8, 0xff, 0xda, 0, 2}, rotated[2:]...), 1},
+		{"empty", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if orientation := exifOrientation(tt.data); orientation != tt.orientation {
+				t.Errorf("exifOrientation() = %d, want %d", orientation, tt.orientation)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package pdfb
+
+import (
+	"log"
+	"strings"
+	"unicode"
+)
+
+// textRun is part of a piece of text written in one font family
+type textRun struct {
+	family string
+	text   string
+}
+
+// returns the font families of a fallback list, eg. "Inter, Noto Sans CJK"
+func fontFamilies(family string) []string {
+	var families []string
+	for _, f := range strings.Split(family, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			families = append(families, f)
+		}
+	}
+	if len(families) == 0 {
+		return []string{""}
+	}
+	return families
+}
+
+// records the characters an imported face has glyphs for, so that the fonts
+// after it in a fallback list are used for any it doesn't have. Faces of a
+// family can have different characters, so each face is recorded by the
+// gofpdf family and style it is added as
+func (p *Pdfb) addFontCoverage(family, style string, data []byte) {
+	coverage, err := ttfCoverage(data)
+	if err != nil {
+		// fonts which can't be read are treated as having every character
+		return
+	}
+	p.fontCoverage[strings.ToLower(family+"|"+style)] = coverage
+}
+
+// returns a function reporting whether the face of a font family which
+// would be used for the current font has a glyph for a character, the
+// core fonts only have the characters of Latin-1
+func (p *Pdfb) glyphCheck(family string) func(r rune) bool {
+	font := p.font
+	font.Family = family
+	font.Underline, font.Strikethrough = false, false
+	face, style := p.fontFace(font)
+
+	if coverage, ok := p.fontCoverage[strings.ToLower(face+"|"+style)]; ok {
+		return coverage.has
+	}
+	if _, ok := p.fontFaces[strings.ToLower(family)]; ok {
+		return func(r rune) bool { return true }
+	}
+	return func(r rune) bool { return r < 256 }
+}
+
+// splits text into runs written in the first family of the font's fallback
+// list which has the glyphs for them. Spaces stay in the run before them.
+func (p *Pdfb) fontRuns(text string) []textRun {
+	families := fontFamilies(p.font.Family)
+	if len(families) < 2 {
+		return []textRun{{families[0], text}}
+	}
+
+	hasGlyph := make([]func(r rune) bool, len(families))
+	for i, f := range families {
+		hasGlyph[i] = p.glyphCheck(f)
+	}
+
+	var runs []textRun
+	start := 0
+	current := ""
+	for i, r := range text {
+		family := current
+		if !unicode.IsSpace(r) || current == "" {
+			family = families[0]
+			for j, f := range families {
+				if hasGlyph[j](r) {
+					family = f
+					break
+				}
+			}
+		}
+
+		if family != current {
+			if i > start {
+				runs = append(runs, textRun{current, text[start:i]})
+			}
+			start, current = i, family
+		}
+	}
+	if start < len(text) {
+		runs = append(runs, textRun{current, text[start:]})
+	}
+	return runs
+}
+
+// reports whether text can be written in the current font without switching
+// to the fonts of the fallback list, which is when it is a single run in the
+// first font of the list
+func (p *Pdfb) inPrimaryFont(runs []textRun) bool {
+	return len(runs) <= 1 && (len(runs) == 0 || runs[0].family == fontFamilies(p.font.Family)[0])
+}
+
+// sets the font in gofpdf to family, keeping the rest of the current font
+func (p *Pdfb) useFontFamily(family string) {
+	font := p.font
+	font.Family = family
+	face, styleStr := p.fontFace(font)
+	p.pdf.SetFont(face, styleStr, p.font.Size)
+
+	// check for errors re: fonts
+	if p.pdf.Err() {
+		log.Fatalln(p.pdf.Error())
+	}
+}
+
+// reports whether a font family has been imported, is a core font or is
+// the default font, which is imported when it is first used
+func (p *Pdfb) fontExists(family string) bool {
+	if strings.EqualFold(family, DefaultFont) {
+		return true
+	}
+	if _, ok := p.fontFaces[strings.ToLower(family)]; ok {
+		return true
+	}
+	for _, f := range coreFonts {
+		if strings.EqualFold(f, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// writes text flowing from the cursor, using the fonts of the fallback
+// list for characters the font doesn't have. gofpdf carries on from the
+// end of each run, so lines wrap in the right place across fonts
+func (p *Pdfb) writeText(text string, link string) {
+	runs := p.fontRuns(text)
+	switchFont := !p.inPrimaryFont(runs)
+	for _, run := range runs {
+		if switchFont {
+			p.useFontFamily(run.family)
+		}
+		if link != "" {
+			p.pdf.WriteLinkString(p.lineHeight, run.text, link)
+		} else {
+			p.pdf.Write(p.lineHeight, run.text)
+		}
+	}
+	if switchFont {
+		p.useFontFamily(p.font.Family)
+	}
+}
+
+// returns the width of text, measuring each run in the font it is written in
+func (p *Pdfb) stringWidth(text string) float64 {
+	runs := p.fontRuns(text)
+	if p.inPrimaryFont(runs) {
+		return p.pdf.GetStringWidth(text)
+	}
+
+	width := 0.0
+	for _, run := range runs {
+		p.useFontFamily(run.family)
+		width += p.pdf.GetStringWidth(run.text)
+	}
+	p.useFontFamily(p.font.Family)
+	return width
+}
+
+// returns the width of each character of text, measured in the font it is
+// written in, so that lines can be broken without measuring them again
+func (p *Pdfb) runeWidths(text string) []float64 {
+	runs := p.fontRuns(text)
+	switchFont := !p.inPrimaryFont(runs)
+	var widths []float64
+	for _, run := range runs {
+		if switchFont {
+			p.useFontFamily(run.family)
+		}
+		for _, r := range run.text {
+			widths = append(widths, p.pdf.GetStringWidth(string(r)))
+		}
+	}
+	if switchFont {
+		p.useFontFamily(p.font.Family)
+	}
+	return widths
+}
+
+// writes text in a cell w wide in the same way as gofpdf's CellFormat,
+// using the fonts of the fallback list for characters the font doesn't have
+func (p *Pdfb) cellText(w, h float64, text, alignStr string, link int) {
+	runs := p.fontRuns(text)
+	if p.inPrimaryFont(runs) {
+		p.pdf.CellFormat(w, h, text, "", 0, alignStr, false, link, "")
+		return
+	}
+
+	// work out where the text starts from the horizontal alignment, then
+	// write each run in a cell of its own with the vertical alignment.
+	// gofpdf pads text at the sides of a cell by the cell margin
+	x, y := p.pdf.GetXY()
+	margin := p.pdf.GetCellMargin()
+	width := p.stringWidth(text)
+	start := x + margin
+	switch {
+	case strings.Contains(alignStr, "C"):
+		start = x + (w-width)/2
+	case strings.Contains(alignStr, "R"):
+		start = x + w - margin - width
+	}
+	vertical := strings.Trim(alignStr, "LCR")
+
+	for _, run := range runs {
+		p.useFontFamily(run.family)
+		runWidth := p.pdf.GetStringWidth(run.text)
+		p.pdf.SetXY(start-margin, y)
+		p.pdf.CellFormat(runWidth+margin*2, h, run.text, "", 0, "L"+vertical, false, link, "")
+		start += runWidth
+		// the first run may have moved onto a new page
+		y = p.pdf.GetY()
+	}
+	p.useFontFamily(p.font.Family)
+
+	// leave the cursor at the end of the cell, as CellFormat does
+	p.pdf.SetXY(x+w, y)
+}
+
+// writes text wrapped into lines w wide in the same way as gofpdf's MultiCell,
+// using the fonts of the fallback list for characters the font doesn't have
+func (p *Pdfb) multiCellText(w, h float64, text, alignStr string) {
+	if p.inPrimaryFont(p.fontRuns(text)) {
+		p.pdf.MultiCell(w, h, text, "", alignStr, false)
+		return
+	}
+
+	x := p.pdf.GetX()
+	if w == 0 {
+		_, _, right, _ := p.pdf.GetMargins()
+		w = p.GetPageWidth() - right - x
+	}
+	width := w - p.pdf.GetCellMargin()*2
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		line, rest := p.fitLine(paragraph, width, true)
+		for {
+			p.pdf.SetX(x)
+			p.cellText(w, h, line, alignStr, 0)
+			p.pdf.Ln(h)
+			if rest == "" {
+				break
+			}
+			line, rest = p.fitLine(rest, width, true)
+		}
+	}
+}
+
+// writes text with its baseline at x, y in the same way as gofpdf's Text,
+// using the fonts of the fallback list for characters the font doesn't have
+func (p *Pdfb) textAt(x, y float64, text string) {
+	runs := p.fontRuns(text)
+	if p.inPrimaryFont(runs) {
+		p.pdf.Text(x, y, text)
+		return
+	}
+
+	for _, run := range runs {
+		p.useFontFamily(run.family)
+		p.pdf.Text(x, y, run.text)
+		x += p.pdf.GetStringWidth(run.text)
+	}
+	p.useFontFamily(p.font.Family)
+}
@@ -0,0 +1,161 @@
+package pdfb
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Figure defines an image with a caption
+//
+// Image is the image file, Caption is written below the image or above it
+// when CaptionPosition is above, and Numbered figures have their caption
+// prefixed with the figure number eg. "Figure 3: ". AltText describes the
+// image for readers who can't see it, and is kept with the figure's details.
+type Figure struct {
+	Image           string
+	Caption         string
+	AltText         string
+	Numbered        bool
+	CaptionPosition string
+}
+
+// FigureInfo describes a figure which has been inserted, for use
+// in lists of figures or when exporting the text of the document
+type FigureInfo struct {
+	Number  int
+	Caption string
+	AltText string
+	Image   string
+	Page    int
+}
+
+// Figure is used to insert an image with a caption, the caption is aligned
+// with the image and both are kept on the same page when the figure is at
+// the cursor (y is GetY())
+// Use 0 in place of w or h to keep the aspect ratio
+func (p *Pdfb) Figure(figure Figure, align string, x, y, w, h float64, opts ...ImageOptions) {
+	// check if image exists
+	if !fileExists(figure.Image) {
+		log.Fatalf("Image could not be located (%s)\n", figure.Image)
+	}
+
+	var o ImageOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Float != "" {
+		log.Fatalf("Figures can't be floated (%s)\n", o.Float)
+	}
+
+	above := false
+	switch strings.ToLower(figure.CaptionPosition) {
+	case "", "below":
+	case "above":
+		above = true
+	default:
+		log.Fatalf("Invalid caption position supplied to Figure (%s)\n", figure.CaptionPosition)
+	}
+
+	// figures flowing from the cursor start below any floating image before
+	// them, and are kept on one page with their caption
+	flowing := y == p.GetY()
+	p.clearFloat()
+
+	img := p.readImageFile(figure.Image)
+	w, h = imageSize(img, o, w, h)
+
+	number := 0
+	caption := figure.Caption
+	if figure.Numbered {
+		number = p.figureNumber() + 1
+		caption = fmt.Sprintf("Figure %d: %s", number, caption)
+	}
+
+	// copy current font
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	gap := p.lineHeight / 2
+
+	captionFont := p.captionFont()
+	p.SetFont(captionFont)
+
+	// keep the image and caption together
+	captionHeight := 0.0
+	if caption != "" {
+		captionHeight = float64(len(p.pdf.SplitText(caption, w)))*p.lineHeight + gap/2
+	}
+	if flowing {
+		y = p.fitOnPage(p.GetY(), h+captionHeight)
+	}
+	x = p.alignImage(align, "", x, w)
+
+	imageY := y
+	if above {
+		p.writeCaption(caption, align, x, y, w)
+		imageY += captionHeight
+	}
+
+	// draw the image with the document font, so it is restored correctly
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+	p.paintImage(img, o, x, imageY, w, h)
+
+	if !above {
+		p.SetFont(captionFont)
+		p.writeCaption(caption, align, x, imageY+h+gap/2, w)
+		p.SetFont(currentFont)
+		p.SetLineHeight(currentLH)
+	}
+
+	// move the cursor past the figure
+	p.pdf.SetY(y + h + captionHeight + gap)
+
+	p.figures = append(p.figures, FigureInfo{
+		Number:  number,
+		Caption: figure.Caption,
+		AltText: figure.AltText,
+		Image:   figure.Image,
+		Page:    p.pdf.PageNo(),
+	})
+
+	p.checkpoint("Figure printed")
+}
+
+// GetFigures is used to get the details of the figures inserted so far
+func (p *Pdfb) GetFigures() []FigureInfo {
+	return p.figures
+}
+
+// returns the number of the last numbered figure
+func (p *Pdfb) figureNumber() int {
+	for i := len(p.figures) - 1; i >= 0; i-- {
+		if p.figures[i].Number > 0 {
+			return p.figures[i].Number
+		}
+	}
+	return 0
+}
+
+// returns the font used for captions, smaller italic text in the current font
+func (p *Pdfb) captionFont() Font {
+	return Font{
+		Family: p.font.Family,
+		Italic: true,
+		Size:   p.font.Size * 0.85,
+	}
+}
+
+// writes the caption of a figure in the current font at x, y, aligned
+// in the same way as the image it belongs to
+func (p *Pdfb) writeCaption(caption, align string, x, y, w float64) {
+	if caption == "" {
+		return
+	}
+	if align == "" {
+		align = "l"
+	}
+
+	p.pdf.SetXY(x, y)
+	p.multiCellText(w, p.lineHeight, caption, p.makeAlignStr(align))
+}
@@ -0,0 +1,145 @@
+package pdfb
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// imageFloat is an image floated to one side of the page, which text
+// wraps around by narrowing the margin on that side until the text is
+// below the image
+type imageFloat struct {
+	bottom  float64
+	column  int
+	lMargin float64
+	page    int
+	rMargin float64
+	x       float64
+}
+
+// starts text wrapping around a floating image on side (l, left, r or right)
+// from top to bottom, which takes up width of the left or right of the column
+func (p *Pdfb) startFloat(side string, top, bottom, width float64) {
+	left, _, right, _ := p.pdf.GetMargins()
+	p.float = &imageFloat{
+		bottom:  bottom,
+		column:  p.column,
+		lMargin: left,
+		page:    p.pdf.PageNo(),
+		rMargin: right,
+	}
+
+	if side == "l" || side == "left" {
+		p.pdf.SetLeftMargin(left + width)
+	} else {
+		p.pdf.SetRightMargin(right + width)
+	}
+	p.float.x, _, _, _ = p.pdf.GetMargins()
+	p.pdf.SetXY(p.float.x, top)
+}
+
+// stops wrapping text around the floating image once the cursor is below it,
+// or on another page or column
+func (p *Pdfb) updateFloat() {
+	f := p.float
+	if f == nil {
+		return
+	}
+	if f.page == p.pdf.PageNo() && f.column == p.column {
+		if p.GetY() < f.bottom {
+			return
+		}
+		p.pdf.SetLeftMargin(f.lMargin)
+		p.pdf.SetRightMargin(f.rMargin)
+		if p.GetX() == f.x {
+			p.pdf.SetX(f.lMargin)
+		}
+	}
+	p.float = nil
+}
+
+// moves the cursor below the floating image, if there is one
+func (p *Pdfb) clearFloat() {
+	f := p.float
+	if f == nil {
+		return
+	}
+	if f.page == p.pdf.PageNo() && f.column == p.column && p.GetY() < f.bottom {
+		p.pdf.SetY(f.bottom)
+	}
+	p.updateFloat()
+}
+
+// writes text around the floating image one line at a time, as gofpdf
+// only works out the width of each line from the margins once.
+// Returns the text left to write once the cursor is below the image
+func (p *Pdfb) writeAroundFloat(text string) string {
+	for p.float != nil && text != "" {
+		line, rest, newline := strings.Cut(text, "\n")
+		left, _, right, _ := p.pdf.GetMargins()
+		fit, over := p.fitLine(line, p.GetPageWidth()-right-p.GetX(), p.GetX() <= left)
+
+		if fit != "" {
+			p.writeText(fit, "")
+		}
+		if over == "" && !newline {
+			return ""
+		}
+
+		p.pdf.Ln(p.lineHeight)
+		p.updateFloat()
+
+		text = over
+		if newline {
+			if over != "" {
+				text += "\n"
+			}
+			text += rest
+		}
+	}
+	return text
+}
+
+// splits line into the part which fits in width, breaking at the last
+// space which fits, and the rest of the line. Words are only broken up
+// when they are too long for a line of their own, if lineStart is false
+// a long word is moved to the next line first
+func (p *Pdfb) fitLine(line string, width float64, lineStart bool) (string, string) {
+	widths := p.runeWidths(line)
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	if total <= width {
+		return line, ""
+	}
+
+	space := -1
+	used := 0.0
+	n := 0
+	for i, c := range line {
+		if c == ' ' {
+			space = i
+		}
+		end := i + utf8.RuneLen(c)
+		used += widths[n]
+		n++
+		if used <= width {
+			continue
+		}
+
+		switch {
+		case space >= 0:
+			return line[:space], line[space+1:]
+		case !lineStart:
+			// start the word on the next line
+			return "", line
+		case i == 0:
+			// a single character wider than the line
+			return line[:end], line[end:]
+		default:
+			return line[:i], line[i:]
+		}
+	}
+	return line, ""
+}
@@ -0,0 +1,273 @@
+package pdfb
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FontFace defines one face of a font family, eg.
+// FontFace{File: "RobotoMono-Thin.ttf", Weight: 100}
+//
+// Weight goes from 100 (thin) to 900 (black), with 0 meaning 400 (regular),
+// and Stretch is the width of the face in the same way as Font.Stretch
+type FontFace struct {
+	File    string
+	Weight  int
+	Italic  bool
+	Stretch string
+}
+
+// fontFace is an imported face of a font family, which is added to gofpdf
+// as the family and style given
+type fontFace struct {
+	family  string
+	style   string
+	weight  int
+	stretch float64
+	italic  bool
+}
+
+// ImportFontFamily is used to import the faces of a font family with any
+// weight or width, eg. thin, light, semibold or condensed faces. The face
+// closest to the weight and width of the font is used when writing text.
+func (p *Pdfb) ImportFontFamily(fontName, fontDir string, faces []FontFace) {
+	for _, face := range faces {
+		data, err := os.ReadFile(path.Join(fontDir, face.File))
+		if err != nil {
+			log.Fatalf("Font could not be read (%s)\n", err)
+		}
+		p.ImportFontFaceBytes(fontName, face, data)
+	}
+}
+
+// ImportFontFaceBytes is used to import a face of a font family from the
+// data of a TrueType font file, face.File is not used
+func (p *Pdfb) ImportFontFaceBytes(fontName string, face FontFace, data []byte) {
+	if face.Weight < 0 || face.Weight > 1000 {
+		log.Fatalf("Invalid font weight supplied to ImportFontFamily (%d)\n", face.Weight)
+	}
+
+	if err := p.importFontFace(fontName, face, data); err != nil {
+		log.Fatalf("Font could not be imported (%s: %s)\n", fontName, err)
+	}
+
+	p.checkpoint("Font face imported")
+}
+
+// imports a face of a font family, returning an error rather than stopping
+// if the font can't be used, so that system fonts which can't be embedded
+// can be skipped
+func (p *Pdfb) importFontFace(fontName string, face FontFace, data []byte) error {
+	weight := face.Weight
+	if weight == 0 {
+		weight = 400
+	}
+	stretch := fontStretch(face.Stretch)
+
+	// gofpdf only embeds TrueType fonts with all of the tables it needs
+	if err := ttfCheck(data); err != nil {
+		return err
+	}
+
+	// each face is added to gofpdf as its own family
+	style := ""
+	if face.Italic {
+		style = "i"
+	}
+	family := fmt.Sprintf("%s-%d-%g", fontName, weight, stretch)
+	p.pdf.AddUTF8FontFromBytes(family, style, data)
+
+	// check for errors re: fonts, clearing them so that the document
+	// can carry on if the face is skipped
+	if p.pdf.Err() {
+		err := p.pdf.Error()
+		p.pdf.ClearError()
+		return err
+	}
+
+	p.addFontCoverage(family, style, data)
+	p.addFontFace(fontName, fontFace{
+		family:  family,
+		style:   style,
+		weight:  weight,
+		stretch: stretch,
+		italic:  face.Italic,
+	})
+	return nil
+}
+
+// adds a face to the faces of a font family, replacing any face
+// with the same weight, width and style
+func (p *Pdfb) addFontFace(fontName string, face fontFace) {
+	key := strings.ToLower(fontName)
+	faces := p.fontFaces[key]
+	for i, f := range faces {
+		if f.weight == face.weight && f.stretch == face.stretch && f.italic == face.italic {
+			faces[i] = face
+			return
+		}
+	}
+	p.fontFaces[key] = append(faces, face)
+}
+
+// returns the gofpdf family and style string to use for a font, picking the
+// closest face of the family imported using the CSS font matching rules.
+// Fonts which aren't imported, such as the core fonts, are used as they are
+func (p *Pdfb) fontFace(font Font) (string, string) {
+	// underline and strikethrough are drawn by gofpdf for every face
+	var decoration string
+	if font.Underline {
+		decoration += "u"
+	}
+	if font.Strikethrough {
+		decoration += "s"
+	}
+
+	// the first font of a fallback list is the font used, the others are
+	// only used for characters it doesn't have
+	font.Family = fontFamilies(font.Family)[0]
+	if strings.EqualFold(font.Family, DefaultFont) {
+		p.importDefaultFont()
+	}
+
+	faces := p.fontFaces[strings.ToLower(font.Family)]
+	if len(faces) == 0 {
+		styleStr := ""
+		if font.Bold {
+			styleStr += "b"
+		}
+		if font.Italic {
+			styleStr += "i"
+		}
+		return font.Family, styleStr + decoration
+	}
+
+	weight := font.Weight
+	if weight == 0 {
+		weight = 400
+	}
+	if font.Bold && weight < 700 {
+		weight = 700
+	}
+
+	face := matchFontFace(faces, weight, fontStretch(font.Stretch), font.Italic)
+	return face.family, face.style + decoration
+}
+
+// picks the face closest to the weight, width and style wanted, narrowing
+// down the faces by width, then style, then weight as CSS does
+func matchFontFace(faces []fontFace, weight int, stretch float64, italic bool) fontFace {
+	// width: narrower widths are preferred for normal or condensed widths,
+	// and wider widths for expanded widths
+	widths := make([]float64, len(faces))
+	for i, f := range faces {
+		widths[i] = f.stretch
+	}
+	width := closest(widths, stretch, stretch <= 100)
+	faces = filterFaces(faces, func(f fontFace) bool { return f.stretch == width })
+
+	// style: the same style if there is one
+	if matching := filterFaces(faces, func(f fontFace) bool { return f.italic == italic }); len(matching) > 0 {
+		faces = matching
+	}
+
+	// weight: lighter weights are preferred below 400 and heavier above 500,
+	// between them the weights up to 500 are tried first, then lighter weights
+	weights := make([]float64, len(faces))
+	for i, f := range faces {
+		weights[i] = float64(f.weight)
+	}
+	var w float64
+	switch {
+	case weight < 400:
+		w = closest(weights, float64(weight), true)
+	case weight > 500:
+		w = closest(weights, float64(weight), false)
+	default:
+		w = -1
+		for _, candidate := range weights {
+			if candidate >= float64(weight) && candidate <= 500 && (w < 0 || candidate < w) {
+				w = candidate
+			}
+		}
+		if w < 0 {
+			w = closest(weights, float64(weight), true)
+		}
+	}
+
+	for _, f := range faces {
+		if float64(f.weight) == w {
+			return f
+		}
+	}
+	return faces[0]
+}
+
+// returns the value closest to want, looking at values at or below it first
+// when lower is true, or at or above it first when it isn't
+func closest(values []float64, want float64, lower bool) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	below, above := -1, -1
+	for i, v := range sorted {
+		if v <= want {
+			below = i
+		}
+		if v >= want && above < 0 {
+			above = i
+		}
+	}
+
+	if lower && below >= 0 || above < 0 {
+		return sorted[below]
+	}
+	return sorted[above]
+}
+
+// returns the faces for which keep returns true
+func filterFaces(faces []fontFace, keep func(f fontFace) bool) []fontFace {
+	var kept []fontFace
+	for _, f := range faces {
+		if keep(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// returns the width of a font as a percentage, from a keyword such as
+// condensed or a percentage eg. "75%"
+func fontStretch(stretch string) float64 {
+	switch strings.ToLower(strings.TrimSpace(stretch)) {
+	case "", "normal":
+		return 100
+	case "ultra-condensed":
+		return 50
+	case "extra-condensed":
+		return 62.5
+	case "condensed":
+		return 75
+	case "semi-condensed":
+		return 87.5
+	case "semi-expanded":
+		return 112.5
+	case "expanded":
+		return 125
+	case "extra-expanded":
+		return 150
+	case "ultra-expanded":
+		return 200
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(stretch), "%"), 64)
+	if err != nil || percent <= 0 {
+		log.Fatalf("Invalid font stretch (%s)\n", stretch)
+	}
+	return percent
+}
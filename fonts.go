@@ -1,14 +1,32 @@
 package pdfb
 
 import (
+	"embed"
+	"io/fs"
 	"log"
+	"os"
 	"path"
 	"strings"
 )
 
-// var stdFonts = []string{"courier", "helvetica", "arial", "times", "symbol", "zapfdingbats"}
+// DefaultFont is the name of the font family used by default, DejaVu Sans
+// Condensed, which is bundled so that Unicode text works out of the box
+const DefaultFont = "DejaVu"
+
+//go:embed fonts/*.ttf
+var defaultFonts embed.FS
+
+// the fonts built into gofpdf, which don't need to be imported
+var coreFonts = []string{"courier", "helvetica", "arial", "times", "symbol", "zapfdingbats"}
 
 // Font defines a font
+//
+// Family can be a list of fonts to fall back on, eg. "Inter, Noto Sans CJK",
+// text is written in the first font of the list which has its characters.
+// Weight goes from 100 (thin) to 900 (black), with 0 meaning 400 (regular),
+// or 700 when Bold is set. Stretch is the width of the font, such as
+// condensed, normal or expanded, or a percentage eg. "75%".
+// The closest weight and width imported for the family are used.
 type Font struct {
 	Family        string
 	Size          float64
@@ -16,24 +34,8 @@ type Font struct {
 	Italic        bool
 	Underline     bool
 	Strikethrough bool
-}
-
-// makes a font styleStr from the stored font style information
-func (p *Pdfb) makeFontStyleStr() (styleStr string) {
-	if p.font.Bold {
-		styleStr += "b"
-	}
-	if p.font.Italic {
-		styleStr += "i"
-	}
-	if p.font.Underline {
-		styleStr += "u"
-	}
-	if p.font.Strikethrough {
-		styleStr += "s"
-	}
-
-	return
+	Weight        int
+	Stretch       string
 }
 
 // creates a copy of a font with the same font properties
@@ -45,6 +47,8 @@ func (p *Pdfb) fontCopy(f Font) Font {
 		Italic:        f.Italic,
 		Underline:     f.Underline,
 		Strikethrough: f.Strikethrough,
+		Weight:        f.Weight,
+		Stretch:       f.Stretch,
 	}
 }
 
@@ -53,9 +57,9 @@ func (p *Pdfb) SetFont(font Font) {
 	if font.Size == 0 {
 		font.Size = p.font.Size
 	}
-	// if font.Family == "" {
-	// 	font.Family = p.font.Family
-	// }
+	if font.Family == "" {
+		font.Family = p.font.Family
+	}
 	// if strings.ToLower(font.Family) == "default" {
 	// 	font.Family = "Inter"
 	// }
@@ -67,13 +71,23 @@ func (p *Pdfb) SetFont(font Font) {
 	// call this after SetFontSize to set the new p.font
 	p.font = font
 
-	// set font within pdf
-	p.pdf.SetFont(font.Family, p.makeFontStyleStr(), font.Size)
+	// set font within pdf, using the closest face of the family
+	family, styleStr := p.fontFace(font)
+	p.pdf.SetFont(family, styleStr, font.Size)
+	p.fontSet = true
 
 	// check for errors re: fonts
 	if p.pdf.Err() {
 		log.Fatalln(p.pdf.Error())
 	}
+
+	// the fonts to fall back on are only set when they are used,
+	// so check that they exist now
+	for _, family := range fontFamilies(font.Family)[1:] {
+		if !p.fontExists(family) {
+			log.Fatalf("Font could not be found (%s)\n", family)
+		}
+	}
 }
 
 // GetFont is used to get the font
@@ -103,23 +117,96 @@ type FontStyle struct {
 // ImportFont is used to import custom fonts
 func (p *Pdfb) ImportFont(fontName, fontDir string, fontStyles []FontStyle) {
 	for _, fontStyle := range fontStyles {
-		style := strings.ToLower(fontStyle.Style)
-		var styleStr string
-
-		switch {
-		case style == "" || style == "regular":
-		case style == "b" || style == "bold":
-			styleStr += "b"
-		case style == "i" || style == "italic":
-			styleStr += "i"
-		case style == "bi" || style == "bolditalic":
-			styleStr += "bi"
-		default:
-			log.Fatalf("Invalid font style supplied to ImportFont (%s)\n", fontStyle.Style)
+		data, err := os.ReadFile(path.Join(fontDir, fontStyle.File))
+		if err != nil {
+			log.Fatalf("Font could not be read (%s)\n", err)
+		}
+		p.ImportFontBytes(fontName, fontStyle.Style, data)
+	}
+}
+
+// ImportFontFS is used to import custom fonts from a file system, such as
+// fonts embedded in the binary with go:embed
+//
+//	//go:embed fonts
+//	var fonts embed.FS
+//
+//	p.ImportFontFS(fonts, "Inter", "fonts", []pdfb.FontStyle{{"Inter-Regular.ttf", "Regular"}})
+func (p *Pdfb) ImportFontFS(fsys fs.FS, fontName, fontDir string, fontStyles []FontStyle) {
+	for _, fontStyle := range fontStyles {
+		data, err := fs.ReadFile(fsys, path.Join(fontDir, fontStyle.File))
+		if err != nil {
+			log.Fatalf("Font could not be read (%s)\n", err)
 		}
+		p.ImportFontBytes(fontName, fontStyle.Style, data)
+	}
+}
+
+// ImportFontBytes is used to import a custom font from the data of a
+// TrueType font file, style is regular, bold, italic or bolditalic
+func (p *Pdfb) ImportFontBytes(fontName, style string, data []byte) {
+	styleStr := fontStyleStr(style)
+	p.pdf.AddUTF8FontFromBytes(fontName, styleStr, data)
+
+	// check for errors re: fonts
+	if p.pdf.Err() {
+		log.Fatalf("Font could not be imported (%s: %s)\n", fontName, p.pdf.Error())
+	}
+
+	p.addFontCoverage(fontName, styleStr, data)
+
+	weight := 400
+	if strings.Contains(styleStr, "b") {
+		weight = 700
+	}
+	p.addFontFace(fontName, fontFace{
+		family:  fontName,
+		style:   styleStr,
+		weight:  weight,
+		stretch: 100,
+		italic:  strings.Contains(styleStr, "i"),
+	})
+}
+
+// returns the gofpdf style string for a FontStyle's Style
+func fontStyleStr(style string) (styleStr string) {
+	switch strings.ToLower(style) {
+	case "", "regular":
+	case "b", "bold":
+		styleStr = "b"
+	case "i", "italic":
+		styleStr = "i"
+	case "bi", "bolditalic":
+		styleStr = "bi"
+	default:
+		log.Fatalf("Invalid font style supplied to ImportFont (%s)\n", style)
+	}
+
+	return
+}
 
-		p.pdf.AddUTF8Font(fontName, styleStr, path.Join(fontDir, fontStyle.File))
+// imports the bundled DejaVu Sans Condensed font, used as the default font
+// so that text outside of Latin-1 can be written without importing a font.
+// It is imported the first time it is used, as parsing it is slow
+func (p *Pdfb) importDefaultFont() {
+	if _, ok := p.fontFaces[strings.ToLower(DefaultFont)]; ok {
+		return
 	}
+
+	for _, fontStyle := range []FontStyle{
+		{"DejaVuSansCondensed.ttf", "Regular"},
+		{"DejaVuSansCondensed-Bold.ttf", "Bold"},
+		{"DejaVuSansCondensed-Oblique.ttf", "Italic"},
+		{"DejaVuSansCondensed-BoldOblique.ttf", "BoldItalic"},
+	} {
+		data, err := defaultFonts.ReadFile(path.Join("fonts", fontStyle.File))
+		if err != nil {
+			log.Fatalf("Font could not be read (%s)\n", err)
+		}
+		p.ImportFontBytes(DefaultFont, fontStyle.Style, data)
+	}
+
+	p.checkpoint("Default font imported")
 }
 
 // SetForeground is used to set the text colour
@@ -2,4 +2,8 @@ module github.com/vqvw/pdfb
 
 go 1.22.1
 
-require github.com/jung-kurt/gofpdf v1.16.2
+require (
+	github.com/boombuler/barcode v1.1.0
+	github.com/disintegration/gift v1.2.1
+	github.com/jung-kurt/gofpdf v1.16.2
+)
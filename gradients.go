@@ -0,0 +1,248 @@
+package pdfb
+
+import (
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Gradient defines a linear or radial gradient used to fill a shape
+//
+// Kind is linear (the default) or radial.
+// Stops are hex colours, optionally followed by a percentage position along
+// the gradient eg. []string{"#f00", "#ff0 30%", "#00f"}, stops without
+// a position are spread evenly between their neighbours. Positions must
+// end with %, so "#ff0 0.3" is invalid.
+//
+// Linear gradients run at Angle, in degrees counter-clockwise,
+// where 0 goes from left to right and 90 from bottom to top.
+//
+// Radial gradients spread out from the centre given by CentreX and CentreY,
+// as fractions (0-1) of the shape's width and height, where nil means the
+// middle of the shape, eg. CentreX: &zero, CentreY: &zero for the top left.
+// Radius is also a fraction of the shape's size, and defaults to reaching
+// the furthest corner.
+type Gradient struct {
+	Kind    string
+	Stops   []string
+	Angle   float64
+	CentreX *float64
+	CentreY *float64
+	Radius  float64
+}
+
+// the most rings painted for each band of a radial gradient
+const maxGradientRings = 64
+
+// gradientStop is a colour at an offset (0-1) along a gradient
+type gradientStop struct {
+	offset  float64
+	r, g, b int
+}
+
+// parses the colour stops of a gradient
+func (g *Gradient) stops() []gradientStop {
+	if len(g.Stops) == 0 {
+		log.Fatalln("Gradients must have at least one colour stop")
+	}
+
+	stops := make([]gradientStop, len(g.Stops))
+	positioned := make([]bool, len(g.Stops))
+	for i, stop := range g.Stops {
+		fields := strings.Fields(stop)
+		if len(fields) == 0 || len(fields) > 2 {
+			log.Fatalf("Invalid gradient colour stop (%s)\n", stop)
+		}
+		stops[i].r, stops[i].g, stops[i].b = hexToRGB(fields[0])
+		if len(fields) == 2 {
+			percent, ok := strings.CutSuffix(fields[1], "%")
+			offset, err := strconv.ParseFloat(percent, 64)
+			if !ok || err != nil {
+				log.Fatalf("Invalid gradient colour stop (%s)\n", stop)
+			}
+			stops[i].offset = math.Max(0, math.Min(1, offset/100))
+			positioned[i] = true
+		}
+	}
+
+	// the first and last stops default to the ends of the gradient
+	positioned[0] = true
+	if last := len(stops) - 1; !positioned[last] {
+		stops[last].offset = 1
+		positioned[last] = true
+	}
+
+	// spread the stops without a position between the ones either side,
+	// and stop offsets from going backwards
+	prev := 0
+	for i := 1; i < len(stops); i++ {
+		if !positioned[i] {
+			continue
+		}
+		stops[i].offset = math.Max(stops[i].offset, stops[prev].offset)
+		for j := prev + 1; j < i; j++ {
+			stops[j].offset = stops[prev].offset + (stops[i].offset-stops[prev].offset)*float64(j-prev)/float64(i-prev)
+		}
+		prev = i
+	}
+
+	return stops
+}
+
+// paints a gradient over the rectangle x, y, w, h
+func (p *Pdfb) paintGradient(g *Gradient, x, y, w, h float64) {
+	switch strings.ToLower(g.Kind) {
+	case "", "linear":
+		// the gradient line passes through the centre and is long
+		// enough for the corners to reach its ends
+		angle := g.Angle * math.Pi / 180
+		// y goes down the page, so counter-clockwise angles go up
+		dx, dy := math.Cos(angle), -math.Sin(angle)
+		length := math.Abs(w*dx) + math.Abs(h*dy)
+		cx, cy := x+w/2, y+h/2
+
+		p.paintLinearGradient(g.stops(),
+			cx-dx*length/2, cy-dy*length/2,
+			cx+dx*length/2, cy+dy*length/2,
+			x, y, w, h)
+	case "radial":
+		cx, cy := 0.5, 0.5
+		if g.CentreX != nil {
+			cx = *g.CentreX
+		}
+		if g.CentreY != nil {
+			cy = *g.CentreY
+		}
+		radius := g.Radius
+		if radius <= 0 {
+			radius = math.Max(math.Hypot(cx, cy), math.Hypot(1-cx, cy))
+			radius = math.Max(radius, math.Max(math.Hypot(cx, 1-cy), math.Hypot(1-cx, 1-cy)))
+		}
+
+		p.paintRadialGradient(g.stops(), cx, cy, radius, x, y, w, h)
+	default:
+		log.Fatalf("Invalid gradient kind (%s)\n", g.Kind)
+	}
+}
+
+// paints a linear gradient with any number of stops over the rectangle x, y, w, h,
+// from (x1, y1) to (x2, y2). gofpdf gradients only have two colours, so a band is
+// painted between each pair of stops, clipped to the part of the rectangle it covers
+func (p *Pdfb) paintLinearGradient(stops []gradientStop, x1, y1, x2, y2, x, y, w, h float64) {
+	// there is nothing to fill in a flat rectangle, and the gradient
+	// coordinates below would divide by zero
+	if w == 0 || h == 0 {
+		return
+	}
+	if len(stops) == 1 || (x1 == x2 && y1 == y2) {
+		last := stops[len(stops)-1]
+		p.pdf.SetFillColor(last.r, last.g, last.b)
+		p.pdf.Rect(x, y, w, h, "F")
+		return
+	}
+
+	// converts a point on the page to the gradient coordinates used by gofpdf,
+	// where the rectangle goes from (0, 0) bottom left to (1, 1) top right
+	norm := func(px, py float64) (float64, float64) {
+		return (px - x) / w, 1 - (py-y)/h
+	}
+
+	// direction of the gradient and the perpendicular to it,
+	// long enough to reach past every side of the rectangle
+	length := math.Hypot(x2-x1, y2-y1)
+	ux, uy := (x2-x1)/length, (y2-y1)/length
+	far := math.Hypot(w, h) + math.Hypot(x1-x, y1-y) + length
+
+	for i := 0; i+1 < len(stops); i++ {
+		from, to := stops[i], stops[i+1]
+
+		// start and end of this band along the gradient
+		ax, ay := x1+(x2-x1)*from.offset, y1+(y2-y1)*from.offset
+		bx, by := x1+(x2-x1)*to.offset, y1+(y2-y1)*to.offset
+
+		// the first and last bands continue past the ends of the gradient
+		startX, startY, endX, endY := ax, ay, bx, by
+		if i == 0 {
+			startX, startY = ax-ux*far, ay-uy*far
+		}
+		if i == len(stops)-2 {
+			endX, endY = bx+ux*far, by+uy*far
+		}
+
+		p.pdf.ClipPolygon([]gofpdf.PointType{
+			{X: startX - uy*far, Y: startY + ux*far},
+			{X: startX + uy*far, Y: startY - ux*far},
+			{X: endX + uy*far, Y: endY - ux*far},
+			{X: endX - uy*far, Y: endY + ux*far},
+		}, false)
+
+		nx1, ny1 := norm(ax, ay)
+		nx2, ny2 := norm(bx, by)
+		if ax == bx && ay == by {
+			// stops at the same offset make a hard edge
+			p.pdf.SetFillColor(to.r, to.g, to.b)
+			p.pdf.Rect(x, y, w, h, "F")
+		} else {
+			p.pdf.LinearGradient(x, y, w, h, from.r, from.g, from.b, to.r, to.g, to.b, nx1, ny1, nx2, ny2)
+		}
+
+		p.pdf.ClipEnd()
+	}
+}
+
+// paints a radial gradient with any number of stops over the rectangle x, y, w, h.
+// The centre and radius are fractions of the rectangle's size, so the gradient is
+// stretched into an ellipse to fit its shape. gofpdf gradients only have two colours
+// and start from a single point, so the bands are painted from the outside in, with
+// the band at the centre drawn as a gradient and those around it as thin rings
+func (p *Pdfb) paintRadialGradient(stops []gradientStop, cx, cy, radius, x, y, w, h float64) {
+	last := stops[len(stops)-1]
+	p.pdf.SetFillColor(last.r, last.g, last.b)
+	p.pdf.Rect(x, y, w, h, "F")
+	if len(stops) == 1 {
+		return
+	}
+
+	centreX, centreY := x+cx*w, y+cy*h
+	for i := len(stops) - 2; i >= 0; i-- {
+		from, to := stops[i], stops[i+1]
+		inner, outer := from.offset*radius, to.offset*radius
+		if outer <= inner {
+			continue
+		}
+
+		if inner == 0 {
+			p.pdf.ClipEllipse(centreX, centreY, outer*w, outer*h, false)
+			// gofpdf measures y up from the bottom of the rectangle
+			p.pdf.RadialGradient(x, y, w, h, from.r, from.g, from.b, to.r, to.g, to.b, cx, 1-cy, cx, 1-cy, outer)
+			p.pdf.ClipEnd()
+			continue
+		}
+
+		// rings of about 0.25mm blend smoothly, but there is no need for more
+		// rings than there are shades between the colours, and the number is
+		// capped so that large shapes such as page backgrounds stay small
+		steps := int(math.Ceil((outer - inner) * math.Max(w, h) / 0.25))
+		shades := math.Max(math.Abs(float64(to.r-from.r)), math.Max(math.Abs(float64(to.g-from.g)), math.Abs(float64(to.b-from.b))))
+		steps = int(math.Max(1, math.Min(float64(steps), math.Min(shades, maxGradientRings))))
+		for step := steps; step >= 0; step-- {
+			t := float64(step) / float64(steps)
+			r := inner + (outer-inner)*t
+			p.pdf.SetFillColor(
+				from.r+int(math.Round(float64(to.r-from.r)*t)),
+				from.g+int(math.Round(float64(to.g-from.g)*t)),
+				from.b+int(math.Round(float64(to.b-from.b)*t)),
+			)
+			p.pdf.Ellipse(centreX, centreY, r*w, r*h, 0, "F")
+		}
+	}
+
+	// inside the first stop
+	if first := stops[0]; first.offset > 0 {
+		p.pdf.SetFillColor(first.r, first.g, first.b)
+		p.pdf.Ellipse(centreX, centreY, first.offset*radius*w, first.offset*radius*h, 0, "F")
+	}
+}
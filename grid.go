@@ -0,0 +1,128 @@
+package pdfb
+
+import (
+	"log"
+	"math"
+	"strings"
+)
+
+// GridImage defines an image in an ImageGrid, with an optional caption
+type GridImage struct {
+	Image   string
+	Caption string
+}
+
+// GridOptions defines options for laying out an ImageGrid
+//
+// Fit is contain or cover (the default), CellHeight is the height of each
+// image's cell, three quarters of the cell width by default, and
+// ImageOptions is used to draw each image, eg. for borders or rounded corners
+type GridOptions struct {
+	Fit          string
+	CellHeight   float64
+	ImageOptions ImageOptions
+}
+
+// ImageGrid is used to lay out images in a grid with the given number of
+// columns, with gutter between the cells. Rows which don't fit on the page
+// are moved onto the next page.
+func (p *Pdfb) ImageGrid(images []GridImage, columns int, gutter float64, opts ...GridOptions) {
+	if columns < 1 {
+		log.Fatalf("Image grids must have at least 1 column (%d given)\n", columns)
+	}
+
+	var g GridOptions
+	if len(opts) > 0 {
+		g = opts[0]
+	}
+	o := g.ImageOptions
+	switch fit := strings.ToLower(g.Fit); fit {
+	case "":
+		o.Fit = "cover"
+	case "contain", "cover":
+		o.Fit = fit
+	default:
+		log.Fatalf("Invalid fit supplied to ImageGrid, use contain or cover (%s)\n", g.Fit)
+	}
+	if o.Float != "" {
+		log.Fatalf("Images in a grid can't be floated (%s)\n", o.Float)
+	}
+
+	// grids start below any floating image before them
+	p.clearFloat()
+
+	// copy current font
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	gap := p.lineHeight / 2
+
+	y := p.GetY()
+	for start := 0; start < len(images); start += columns {
+		end := start + columns
+		if end > len(images) {
+			end = len(images)
+		}
+		row := images[start:end]
+
+		// cells fill the width of the column in use, which can change
+		// when the grid moves onto the next page or column, so the row is
+		// measured again after it has been moved
+		cellW, cellH, captionHeight := p.gridRowSize(row, columns, gutter, gap, g.CellHeight)
+		y = p.fitOnPage(y, cellH+captionHeight)
+		cellW, cellH, captionHeight = p.gridRowSize(row, columns, gutter, gap, g.CellHeight)
+		left, _, _, _ := p.pdf.GetMargins()
+
+		for i, image := range row {
+			if !fileExists(image.Image) {
+				log.Fatalf("Image could not be located (%s)\n", image.Image)
+			}
+
+			x := left + float64(i)*(cellW+gutter)
+			p.paintImage(p.readImageFile(image.Image), o, x, y, cellW, cellH)
+
+			if image.Caption != "" {
+				p.SetFont(p.captionFont())
+				p.writeCaption(image.Caption, "c", x, y+cellH+gap/2, cellW)
+				p.SetFont(currentFont)
+				p.SetLineHeight(currentLH)
+			}
+		}
+
+		y += cellH + captionHeight
+		if end < len(images) {
+			y += gutter
+		}
+	}
+
+	// move the cursor past the grid
+	p.pdf.SetY(y + gap)
+
+	p.checkpoint("Image grid printed")
+}
+
+// returns the width and height of the cells in a row of an image grid in the
+// column in use, and the height of the row's longest caption
+func (p *Pdfb) gridRowSize(row []GridImage, columns int, gutter, gap, cellHeight float64) (cellW, cellH, captionHeight float64) {
+	left, _, right, _ := p.pdf.GetMargins()
+	cellW = (p.GetPageWidth() - left - right - gutter*float64(columns-1)) / float64(columns)
+	cellH = cellHeight
+	if cellH <= 0 {
+		cellH = cellW * 0.75
+	}
+
+	// copy current font
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+
+	p.SetFont(p.captionFont())
+	for _, image := range row {
+		if image.Caption != "" {
+			height := float64(len(p.pdf.SplitText(image.Caption, cellW)))*p.lineHeight + gap/2
+			captionHeight = math.Max(captionHeight, height)
+		}
+	}
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+
+	return cellW, cellH, captionHeight
+}
@@ -0,0 +1,336 @@
+package pdfb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageContext describes the page a header or footer is being drawn on
+type PageContext struct {
+	Page        int
+	Pages       int
+	SectionPage int
+	FirstPage   bool
+	Title       string
+	Author      string
+	Subject     string
+	Date        time.Time
+
+	// Chapter is the most recent level 1 heading on or before the page,
+	// Section is the first heading which starts on the page, or "" if none do
+	Chapter string
+	Section string
+
+	// area of the page the header or footer takes up
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+
+	headings []heading
+}
+
+// matches tokens such as {page} and {date:2006-01-02}
+var tokenRegexp = regexp.MustCompile(`\{(\w+)(?::([^}]*))?\}`)
+
+// Expand is used to replace the tokens in a string with the values
+// of the page, the following tokens can be used:
+//
+// {page}, {pages}, {sectionpage}, {title}, {author}, {subject},
+// {chapter}, the most recent level 1 heading, or level N heading using {chapter:N},
+// {section}, the first heading which starts on the page (empty if none do),
+// and {date}, which takes an optional layout eg. {date:2006-01-02}
+func (ctx PageContext) Expand(text string) string {
+	return tokenRegexp.ReplaceAllStringFunc(text, func(token string) string {
+		match := tokenRegexp.FindStringSubmatch(token)
+		switch strings.ToLower(match[1]) {
+		case "page":
+			return strconv.Itoa(ctx.Page)
+		case "pages":
+			return strconv.Itoa(ctx.Pages)
+		case "sectionpage":
+			return strconv.Itoa(ctx.SectionPage)
+		case "chapter":
+			if level, err := strconv.Atoi(match[2]); err == nil {
+				return ctx.Heading(level)
+			}
+			return ctx.Chapter
+		case "section":
+			return ctx.Section
+		case "title":
+			return ctx.Title
+		case "author":
+			return ctx.Author
+		case "subject":
+			return ctx.Subject
+		case "date":
+			if match[2] != "" {
+				return ctx.Date.Format(match[2])
+			}
+			return ctx.Date.Format("2 January 2006")
+		}
+		// leave unknown tokens as they are
+		return token
+	})
+}
+
+// Heading is used to get the most recent heading of the given level
+// which starts on or before the page, as used for running headers
+func (ctx PageContext) Heading(level int) string {
+	return lastHeading(ctx.headings, ctx.Page, level)
+}
+
+// SetHeaderFunc is used to draw the header of the page template in use
+// with a function, which is called for each page once the document is
+// complete. height is the space kept free for the header at the top of the page.
+// The header is used from the next page onwards.
+func (p *Pdfb) SetHeaderFunc(height float64, fn func(ctx PageContext)) {
+	t := p.currentTemplate()
+	t.HeaderHeight = height
+	t.HeaderFunc = fn
+	p.applyTemplate(t)
+
+	p.checkpoint("Header function set")
+}
+
+// SetFooterFunc is used to draw the footer of the page template in use
+// with a function, which is called for each page once the document is
+// complete. height is the space kept free for the footer at the bottom of the page.
+// The footer is used from the next page onwards.
+func (p *Pdfb) SetFooterFunc(height float64, fn func(ctx PageContext)) {
+	t := p.currentTemplate()
+	t.FooterHeight = height
+	t.FooterFunc = fn
+	p.applyTemplate(t)
+
+	p.checkpoint("Footer function set")
+}
+
+// SuppressHeader is used to hide the header on the given pages,
+// or on the current page if no pages are given
+// Eg. for pages containing a full page image
+func (p *Pdfb) SuppressHeader(pages ...int) {
+	if len(pages) == 0 {
+		pages = []int{p.pdf.PageNo()}
+	}
+	for _, page := range pages {
+		p.hiddenHeaders[page] = true
+	}
+
+	p.checkpoint("Header suppressed")
+}
+
+// SuppressFooter is used to hide the footer on the given pages,
+// or on the current page if no pages are given
+func (p *Pdfb) SuppressFooter(pages ...int) {
+	if len(pages) == 0 {
+		pages = []int{p.pdf.PageNo()}
+	}
+	for _, page := range pages {
+		p.hiddenFooters[page] = true
+	}
+
+	p.checkpoint("Footer suppressed")
+}
+
+// reports whether the template has a header on any of its pages
+func (t *PageTemplate) hasHeader() bool {
+	return t.HeaderFunc != nil || len(t.Header) > 0 || len(t.FirstHeader) > 0 || len(t.EvenHeader) > 0
+}
+
+// reports whether the template has a footer on any of its pages
+func (t *PageTemplate) hasFooter() bool {
+	return t.FooterFunc != nil || len(t.Footer) > 0 || len(t.FirstFooter) > 0 || len(t.EvenFooter) > 0
+}
+
+// returns the height of the template's header
+func (t *PageTemplate) headerHeight() float64 {
+	if t.HeaderHeight > 0 {
+		return t.HeaderHeight
+	}
+	return 25.0
+}
+
+// returns the height of the template's footer
+func (t *PageTemplate) footerHeight() float64 {
+	if t.FooterHeight > 0 {
+		return t.FooterHeight
+	}
+	return 25.0
+}
+
+// picks the header or footer content to use on a page
+func pickContent(info *pageInfo, page int, content, first, even []TextAlign) []TextAlign {
+	switch {
+	case info.sectionStart && first != nil:
+		return first
+	case page%2 == 0 && even != nil:
+		return even
+	case page%2 == 0 && info.template.MirrorEven:
+		return mirrorContent(content)
+	}
+	return content
+}
+
+// reverses the order of content and swaps left and right alignments
+func mirrorContent(content []TextAlign) []TextAlign {
+	mirrored := make([]TextAlign, len(content))
+	for i, c := range content {
+		switch strings.ToLower(c.Align) {
+		case "l", "left":
+			c.Align = "right"
+		case "r", "right":
+			c.Align = "left"
+		}
+		mirrored[len(content)-1-i] = c
+	}
+	return mirrored
+}
+
+// returns the last heading of the given level which starts on or before
+// the page, stopping at any heading of a higher level
+func lastHeading(headings []heading, page, level int) string {
+	var text string
+	var latest int
+	for _, h := range headings {
+		if h.page > page || h.page < latest {
+			continue
+		}
+		switch {
+		case h.level == level:
+			text = h.text
+			latest = h.page
+		case h.level < level:
+			text = ""
+			latest = h.page
+		}
+	}
+	return text
+}
+
+// returns the first heading which starts on the page, or "" if there are none
+func firstHeading(headings []heading, page int) string {
+	for _, h := range headings {
+		if h.page == page {
+			return h.text
+		}
+	}
+	return ""
+}
+
+// draws the header, footer and any watermark in front of the content of every
+// page, called once all pages have been added so that pages can be skipped
+// and the number of pages is known
+func (p *Pdfb) drawHeadersFooters() {
+	// the document can be output more than once, eg. SaveAs then
+	// ExportAsBase64, but the headers and footers are only drawn once
+	if p.headersDrawn {
+		return
+	}
+	p.headersDrawn = true
+
+	// headers and footers are drawn outside of the page margins, so the
+	// page break must be turned off to stop new pages being added
+	auto, bottom := p.pdf.GetAutoPageBreak()
+	p.pdf.SetAutoPageBreak(false, bottom)
+
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	currentFG := p.foreground
+
+	var sectionPage int
+	for page := 1; page <= p.pdf.PageCount(); page++ {
+		info, ok := p.pages[page]
+		if !ok {
+			continue
+		}
+		t := &info.template
+
+		if info.sectionStart {
+			sectionPage = 0
+		}
+		sectionPage++
+
+		p.pdf.SetPage(page)
+
+		if info.watermark.inFront() {
+			p.drawWatermark(info.watermark)
+		}
+
+		pageWidth, pageHeight, _ := p.pdf.PageSize(page)
+		ctx := PageContext{
+			Page:        page,
+			Pages:       p.pdf.PageCount(),
+			SectionPage: sectionPage,
+			FirstPage:   info.sectionStart,
+			Chapter:     lastHeading(p.headings, page, 1),
+			Section:     firstHeading(p.headings, page),
+			Title:       p.title,
+			Author:      p.author,
+			Subject:     p.subject,
+			Date:        p.creationDate,
+			X:           0,
+			Width:       pageWidth,
+			headings:    p.headings,
+		}
+
+		if !p.hiddenHeaders[page] {
+			ctx.Y = 0
+			ctx.Height = t.headerHeight()
+			header := pickContent(info, page, t.Header, t.FirstHeader, t.EvenHeader)
+			p.drawHeaderFooter(t, t.HeaderFont, header, t.HeaderFunc, ctx)
+		}
+
+		if !p.hiddenFooters[page] {
+			ctx.Y = pageHeight - t.footerHeight()
+			ctx.Height = t.footerHeight()
+			footer := pickContent(info, page, t.Footer, t.FirstFooter, t.EvenFooter)
+			p.drawHeaderFooter(t, t.FooterFont, footer, t.FooterFunc, ctx)
+		}
+	}
+
+	p.pdf.SetPage(p.pdf.PageCount())
+	p.pdf.SetAutoPageBreak(auto, bottom)
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+	p.SetForeground(currentFG)
+
+	p.checkpoint("Headers and footers printed")
+}
+
+// draws a header or footer in the area given by ctx, using fn if it is set
+// or a row of text sections otherwise
+func (p *Pdfb) drawHeaderFooter(t *PageTemplate, fontFamily string, content []TextAlign, fn func(ctx PageContext), ctx PageContext) {
+	if fn == nil && len(content) == 0 {
+		return
+	}
+
+	margin := p.templateMargin(t)
+	p.pdf.SetXY(margin, ctx.Y)
+
+	if fontFamily == "" {
+		fontFamily = p.font.Family
+	}
+
+	// set font and foreground for header/footer text
+	p.SetFont(Font{
+		Family: fontFamily,
+		Size:   12,
+	})
+	p.SetForeground("#000")
+
+	if fn != nil {
+		fn(ctx)
+		return
+	}
+
+	// create cells for each section
+	sectionWidth := (ctx.Width - margin*2) / float64(len(content))
+	for _, c := range content {
+		p.cellText(sectionWidth, ctx.Height, ctx.Expand(c.Text), "M"+p.makeAlignStr(c.Align), 0)
+	}
+
+	p.checkpoint("Header/footer printed")
+}
@@ -0,0 +1,175 @@
+package pdfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"math"
+
+	"github.com/disintegration/gift"
+)
+
+// ImagePolicy defines how images are prepared before they are embedded,
+// to keep the size of documents down. Zero values leave images as they are.
+//
+// MaxDPI downsamples images with more pixels per inch than this at the
+// size they are drawn, JPEGQuality (1-100) recompresses JPEGs, Grayscale
+// converts images to grayscale and StripMetadata removes EXIF and other
+// metadata from JPEGs
+type ImagePolicy struct {
+	MaxDPI        float64
+	JPEGQuality   int
+	Grayscale     bool
+	StripMetadata bool
+}
+
+// GetImageBytesSaved is used to get the number of bytes the image policy
+// has saved across every image embedded so far
+func (p *Pdfb) GetImageBytesSaved() int {
+	return p.imageBytesSaved
+}
+
+// returns the size in pixels to embed an image at, so that it has no more
+// pixels per inch than the maximum when drawn w by h
+func (p *Pdfb) policySize(img *imageData, w, h float64) (int, int) {
+	maxDPI := p.imagePolicy.MaxDPI
+	if maxDPI <= 0 || w <= 0 || h <= 0 {
+		return img.width, img.height
+	}
+
+	scale := math.Min(w/25.4*maxDPI/float64(img.width), h/25.4*maxDPI/float64(img.height))
+	if scale >= 1 {
+		return img.width, img.height
+	}
+	return int(math.Max(math.Round(float64(img.width)*scale), 1)), int(math.Max(math.Round(float64(img.height)*scale), 1))
+}
+
+// prepares an image for embedding using the image policy, resizing it to
+// width by height pixels, and turns photos the right way up using their
+// EXIF orientation. Returns the data to embed and its format
+func (p *Pdfb) prepareImage(img *imageData, width, height int) ([]byte, string) {
+	policy := p.imagePolicy
+	resize := width != img.width || height != img.height
+	recompress := policy.JPEGQuality > 0 && img.format == "JPG"
+	orient := img.orientation > 1
+
+	data, format := img.data, img.format
+	if !resize && !recompress && !policy.Grayscale && !orient {
+		if policy.StripMetadata && img.format == "JPG" {
+			data = stripJPEGMetadata(img.data)
+		}
+	} else {
+		data, format = p.reencodeImage(img, width, height)
+
+		// recompressing or resizing can make some images larger, eg. paletted
+		// PNGs which are encoded again with full colour, so keep the original
+		// unless it has to be changed to look right
+		if !policy.Grayscale && !orient && len(data) >= len(img.data) {
+			data, format = img.data, img.format
+			if policy.StripMetadata {
+				data = stripJPEGMetadata(img.data)
+			}
+		}
+	}
+
+	// grayscale or turned images can still end up larger, which isn't a saving
+	if saved := len(img.data) - len(data); saved > 0 && policy != (ImagePolicy{}) {
+		p.imageBytesSaved += saved
+		p.checkpoint(fmt.Sprintf("Image policy applied (%d bytes saved, %s)", saved, img.name))
+	}
+
+	return data, format
+}
+
+// decodes an image, turns it the right way up, resizes it and converts it to
+// grayscale if needed, then encodes it again as a JPEG for photos or as a
+// PNG for anything else
+func (p *Pdfb) reencodeImage(img *imageData, width, height int) ([]byte, string) {
+	src, _, err := image.Decode(bytes.NewReader(img.data))
+	if err != nil {
+		log.Fatalf("Image could not be decoded (%s: %s)\n", img.name, err)
+	}
+
+	filters := gift.New()
+	if filter := orientationFilter(img.orientation); filter != nil {
+		filters.Add(filter)
+	}
+	if width != img.width || height != img.height {
+		filters.Add(gift.Resize(width, height, gift.LanczosResampling))
+	}
+	if p.imagePolicy.Grayscale {
+		filters.Add(gift.Grayscale())
+	}
+
+	// grayscale images without transparency only need one channel
+	var dst draw.Image = image.NewNRGBA(filters.Bounds(src.Bounds()))
+	if p.imagePolicy.Grayscale && (img.format == "JPG" || isOpaque(src)) {
+		dst = image.NewGray(filters.Bounds(src.Bounds()))
+	}
+	filters.Draw(dst, src)
+
+	var buf bytes.Buffer
+	if img.format == "JPG" {
+		quality := p.imagePolicy.JPEGQuality
+		if quality <= 0 {
+			quality = 90
+		}
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality})
+	} else {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&buf, dst)
+	}
+	if err != nil {
+		log.Fatalf("Image could not be encoded (%s: %s)\n", img.name, err)
+	}
+
+	if img.format == "JPG" {
+		return buf.Bytes(), "JPG"
+	}
+	return buf.Bytes(), "PNG"
+}
+
+// reports whether an image has no transparent pixels
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// removes the EXIF, XMP and comment segments of a JPEG, keeping the
+// segments needed to display it (JFIF, colour profiles and Adobe)
+func stripJPEGMetadata(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return data
+	}
+
+	out := []byte{0xff, 0xd8}
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xff {
+		marker := data[i+1]
+		// the compressed image data follows the start of scan segment
+		if marker == 0xda {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if end > len(data) {
+			return data
+		}
+
+		// APP1 (EXIF and XMP), APP3 to APP13, APP15 and comments
+		metadata := marker == 0xe1 || (marker >= 0xe3 && marker <= 0xed) || marker == 0xef || marker == 0xfe
+		if !metadata {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	return append(out, data[i:]...)
+}
@@ -0,0 +1,428 @@
+package pdfb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ImageOptions defines options for drawing images
+//
+// Opacity goes from 0 to 1 with 0 treated as fully opaque,
+// BlendMode is one such as Multiply or Screen (see WithBlendMode),
+// Shape crops the image to a circle or rounded rectangle (rounded),
+// whose corners have the given Radius, a tenth of the image size by default.
+//
+// Fit sets how the image fills its box when both w and h are given:
+// fill stretches it (the default), contain fits it inside the box, cover
+// fills the box and crops what overflows, and none keeps its natural size.
+// Padding is the space between the edge of the box and the image, and
+// Border and Shadow are drawn around the box in the shape of the image.
+//
+// Float places the image against the left or right side of the page, with
+// the text written after it wrapping around the image.
+type ImageOptions struct {
+	Opacity   float64
+	BlendMode string
+	Shape     string
+	Radius    float64
+	Fit       string
+	Padding   float64
+	Border    Stroke
+	Shadow    Shadow
+	Float     string
+}
+
+// Shadow defines the shadow drawn behind an image
+//
+// Colour is a hex colour, the shadow is only drawn when it is set,
+// OffsetX and OffsetY default to 1.5mm and Opacity defaults to 0.3
+type Shadow struct {
+	Colour  string
+	OffsetX float64
+	OffsetY float64
+	Opacity float64
+}
+
+// Image is used to insert an image
+// Use 0 in place of w or h to keep the aspect ratio.
+// The cursor is moved below the image, or beside it for floating images.
+// Images at the cursor (y is GetY()) move onto the next page if they don't
+// fit, images placed anywhere else are drawn where they are put.
+func (p *Pdfb) Image(filename, align string, x, y, w, h float64, opts ...ImageOptions) {
+	// check if image exists
+	if !fileExists(filename) {
+		log.Fatalf("Image could not be located (%s)\n", filename)
+	}
+
+	p.drawImage(p.readImageFile(filename), align, x, y, w, h, opts)
+
+	p.checkpoint("Image printed")
+}
+
+// ImageFromReader is used to insert an image read from r, such as an upload
+// or a file stored in a database. name is used in error messages, and format
+// is jpg, png or gif, or empty to detect the format from the image data.
+// Use 0 in place of w or h to keep the aspect ratio
+func (p *Pdfb) ImageFromReader(name string, r io.Reader, format, align string, x, y, w, h float64, opts ...ImageOptions) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		log.Fatalf("Image could not be read (%s: %s)\n", name, err)
+	}
+
+	p.drawImage(p.readImage(name, data, format), align, x, y, w, h, opts)
+
+	p.checkpoint("Image printed")
+}
+
+// ImageFromBytes is used to insert an image from its encoded data, in the
+// same way as ImageFromReader
+func (p *Pdfb) ImageFromBytes(name string, data []byte, format, align string, x, y, w, h float64, opts ...ImageOptions) {
+	p.drawImage(p.readImage(name, data, format), align, x, y, w, h, opts)
+
+	p.checkpoint("Image printed")
+}
+
+// ImageFromGoImage is used to insert an image.Image, such as one which has
+// been generated or edited in Go. The image is embedded as a PNG.
+// Use 0 in place of w or h to keep the aspect ratio
+func (p *Pdfb) ImageFromGoImage(img image.Image, align string, x, y, w, h float64, opts ...ImageOptions) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Fatalf("Image could not be encoded (%s)\n", err)
+	}
+
+	p.drawImage(p.readImage("image.Image", buf.Bytes(), "png"), align, x, y, w, h, opts)
+
+	p.checkpoint("Image printed")
+}
+
+// ImageFromDataURI is used to insert an image from a data URI,
+// eg. "data:image/png;base64,iVBORw0KGgo..."
+// Use 0 in place of w or h to keep the aspect ratio
+func (p *Pdfb) ImageFromDataURI(uri, align string, x, y, w, h float64, opts ...ImageOptions) {
+	data, format := decodeDataURI(uri)
+
+	p.drawImage(p.readImage("data URI", data, format), align, x, y, w, h, opts)
+
+	p.checkpoint("Image printed")
+}
+
+// imageData is an encoded image which hasn't been registered yet, along
+// with its format (JPG, PNG or GIF), its EXIF orientation and its size in
+// pixels once it has been turned the right way up
+type imageData struct {
+	name        string
+	data        []byte
+	format      string
+	orientation int
+	width       int
+	height      int
+}
+
+// returns the natural size of the image, at 72 pixels per inch
+func (img *imageData) size() (float64, float64) {
+	return float64(img.width) * 25.4 / 72, float64(img.height) * 25.4 / 72
+}
+
+// reads an image file, using the extension as the format
+// if it can't be worked out from the contents
+func (p *Pdfb) readImageFile(filename string) *imageData {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Image could not be read (%s)\n", err)
+	}
+
+	format := imageFormat(data)
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+
+	return p.readImage(filename, data, format)
+}
+
+// checks the format of encoded image data and reads its size.
+// name is used in error messages
+func (p *Pdfb) readImage(name string, data []byte, format string) *imageData {
+	if format == "" {
+		format = imageFormat(data)
+	}
+	switch strings.ToLower(format) {
+	case "jpg", "jpeg":
+		format = "JPG"
+	case "png":
+		format = "PNG"
+	case "gif":
+		format = "GIF"
+	default:
+		log.Fatalf("Image format is not supported, use jpg, png or gif (%s)\n", name)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		log.Fatalf("Image could not be read (%s: %s)\n", name, err)
+	}
+
+	img := &imageData{
+		name:        name,
+		data:        data,
+		format:      format,
+		orientation: 1,
+		width:       config.Width,
+		height:      config.Height,
+	}
+
+	// photos turned on their side are stored with their width and height
+	// swapped, the EXIF orientation says how to turn them back
+	if format == "JPG" {
+		img.orientation = exifOrientation(data)
+		if img.orientation >= 5 {
+			img.width, img.height = img.height, img.width
+		}
+	}
+
+	return img
+}
+
+// registers an image with gofpdf under a name made from a hash of its data,
+// so that the same image is only embedded once however it is inserted.
+// w and h are the size the image is drawn at, which the image policy
+// uses to downsample the image. Returns the name to draw the image with
+func (p *Pdfb) registerImage(img *imageData, w, h float64) string {
+	// images prepared differently by the image policy are kept apart
+	width, height := p.policySize(img, w, h)
+	hash := sha256.New()
+	hash.Write(img.data)
+	if p.imagePolicy != (ImagePolicy{}) {
+		fmt.Fprint(hash, p.imagePolicy, width, height)
+	}
+	key := "pdfb-" + hex.EncodeToString(hash.Sum(nil)[:16])
+	if info := p.pdf.GetImageInfo(key); info != nil {
+		return key
+	}
+
+	data, format := p.prepareImage(img, width, height)
+	p.pdf.RegisterImageOptionsReader(key, gofpdf.ImageOptions{ImageType: format}, bytes.NewReader(data))
+	if p.pdf.Err() {
+		log.Fatalf("Image could not be read (%s: %s)\n", img.name, p.pdf.Error())
+	}
+
+	return key
+}
+
+// returns the format of encoded image data using its first few bytes,
+// or an empty string if it isn't jpg, png or gif
+func imageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "jpg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	}
+	return ""
+}
+
+// decodes the data of a data URI, returning the data and the image
+// format given by its media type
+func decodeDataURI(uri string) ([]byte, string) {
+	if !strings.HasPrefix(uri, "data:") {
+		log.Fatalln("Invalid data URI, it must start with data:")
+	}
+	comma := strings.Index(uri, ",")
+	if comma < 0 {
+		log.Fatalln("Invalid data URI, it has no data")
+	}
+	meta, payload := uri[len("data:"):comma], uri[comma+1:]
+
+	var data []byte
+	var err error
+	if strings.HasSuffix(meta, ";base64") {
+		meta = strings.TrimSuffix(meta, ";base64")
+		// whitespace is allowed in base64 data URIs
+		payload = strings.Join(strings.Fields(payload), "")
+		data, err = base64.StdEncoding.DecodeString(payload)
+	} else {
+		var text string
+		text, err = url.PathUnescape(payload)
+		data = []byte(text)
+	}
+	if err != nil {
+		log.Fatalf("Invalid data URI (%s)\n", err)
+	}
+
+	// the media type comes before any parameters eg. image/png;charset=...
+	mediaType := strings.ToLower(strings.SplitN(meta, ";", 2)[0])
+	return data, strings.TrimPrefix(mediaType, "image/")
+}
+
+// draws a registered image, sized and aligned in the same way for every
+// kind of image source. The image is drawn at y, and the cursor is moved
+// below it. Images flowing from the cursor move to the top of the next page
+// or column if they don't fit, those placed anywhere else are drawn where
+// they are put, eg. a background covering the whole page.
+func (p *Pdfb) drawImage(img *imageData, align string, x, y, w, h float64, opts []ImageOptions) {
+	var o ImageOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	// images flowing from the cursor start below any floating image before
+	// them, and move onto the next page or column if they don't fit
+	flowing := y == p.GetY()
+	p.clearFloat()
+
+	w, h = imageSize(img, o, w, h)
+	if flowing {
+		y = p.fitOnPage(p.GetY(), h)
+	}
+	float := strings.ToLower(o.Float)
+	x = p.alignImage(align, float, x, w)
+
+	p.paintImage(img, o, x, y, w, h)
+
+	// move the cursor past the image, or alongside it for text to wrap around
+	gap := p.lineHeight / 2
+	if float == "" {
+		p.pdf.SetY(y + h + gap)
+	} else {
+		p.startFloat(float, y, y+h+gap, w+gap)
+	}
+}
+
+// returns y, or the top of the next page or column after moving onto it
+// if something h high won't fit on the page at y
+func (p *Pdfb) fitOnPage(y, h float64) float64 {
+	auto, bottom := p.pdf.GetAutoPageBreak()
+	_, pageHeight := p.pdf.GetPageSize()
+	if !auto || y+h <= pageHeight-bottom {
+		return y
+	}
+
+	if p.acceptPageBreak() {
+		p.pdf.AddPage()
+	}
+	return p.GetY()
+}
+
+// returns the x position of an image w wide, aligned to the left, right,
+// or centre of the column in use, or floated to one side of it
+func (p *Pdfb) alignImage(align, float string, x, w float64) float64 {
+	left, _, right, _ := p.pdf.GetMargins()
+	right = p.GetPageWidth() - right
+
+	switch float {
+	case "":
+	case "l", "left":
+		return left
+	case "r", "right":
+		return right - w
+	default:
+		log.Fatalf("Invalid float supplied to Image (%s)\n", float)
+	}
+
+	align = strings.ToLower(align)
+	switch {
+	case align == "l" || align == "left" || align == "":
+	case align == "c" || align == "centre":
+		x = left + (right-left)/2 - w/2
+	case align == "r" || align == "right":
+		x = right - w
+	default:
+		log.Fatalf("Invalid alignment supplied to Image (%s)\n", align)
+	}
+	return x
+}
+
+// returns the size of the box for an image, working out w or h from the
+// aspect ratio of the image and its padding if either is 0
+func imageSize(img *imageData, o ImageOptions, w, h float64) (float64, float64) {
+	padding := o.Padding * 2
+	if w == 0 {
+		w = (h-padding)*float64(img.width)/float64(img.height) + padding
+	}
+	if h == 0 {
+		h = (w-padding)*float64(img.height)/float64(img.width) + padding
+	}
+	return w, h
+}
+
+// draws an image in the box x, y, w, h, fitted to the box using its
+// options along with its shadow and border, without moving the cursor
+func (p *Pdfb) paintImage(img *imageData, o ImageOptions, x, y, w, h float64) {
+	// shadow behind the box, in the same shape as the image
+	if o.Shadow.Colour != "" {
+		offsetX, offsetY, opacity := o.Shadow.OffsetX, o.Shadow.OffsetY, o.Shadow.Opacity
+		if offsetX == 0 && offsetY == 0 {
+			offsetX, offsetY = 1.5, 1.5
+		}
+		if opacity == 0 {
+			opacity = 0.3
+		}
+		p.drawShape(x+offsetX, y+offsetY, w, h, Fill{Colour: o.Shadow.Colour, Opacity: opacity}, Stroke{}, p.imageShapePath(o, x+offsetX, y+offsetY, w, h, 0))
+	}
+
+	// the space inside the padding which the image is fitted to
+	innerX, innerY := x+o.Padding, y+o.Padding
+	innerW, innerH := w-o.Padding*2, h-o.Padding*2
+	if innerW <= 0 || innerH <= 0 {
+		log.Fatalf("Image padding is larger than the image (%.2f)\n", o.Padding)
+	}
+
+	// size the image within the space for its fit mode
+	naturalW, naturalH := img.size()
+	imageW, imageH := innerW, innerH
+	switch strings.ToLower(o.Fit) {
+	case "", "fill":
+	case "contain":
+		scale := math.Min(innerW/naturalW, innerH/naturalH)
+		imageW, imageH = naturalW*scale, naturalH*scale
+	case "cover":
+		scale := math.Max(innerW/naturalW, innerH/naturalH)
+		imageW, imageH = naturalW*scale, naturalH*scale
+	case "none":
+		imageW, imageH = naturalW, naturalH
+	default:
+		log.Fatalf("Invalid image fit (%s)\n", o.Fit)
+	}
+	imageX := innerX + (innerW-imageW)/2
+	imageY := innerY + (innerH-imageH)/2
+
+	name := p.registerImage(img, imageW, imageH)
+
+	transparent := drawOpacity(o.Opacity) < 1 || o.BlendMode != ""
+	if transparent {
+		p.setAlpha(drawOpacity(o.Opacity), o.BlendMode)
+	}
+
+	// images larger than the space are cropped to it
+	crop := imageW > innerW+0.01 || imageH > innerH+0.01
+	p.clipImage(o, crop, innerX, innerY, innerW, innerH, o.Padding, func() {
+		p.pdf.Image(name, imageX, imageY, imageW, imageH, false, "", 0, "")
+	})
+
+	if transparent {
+		p.pdf.SetAlpha(p.opacity, p.blendMode)
+	}
+
+	// border around the box
+	if o.Border.visible() {
+		p.drawShape(x, y, w, h, Fill{}, o.Border, p.imageShapePath(o, x, y, w, h, 0))
+	}
+}
@@ -0,0 +1,64 @@
+package pdfb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeDataURI(t *testing.T) {
+	tests := []struct {
+		name   string
+		uri    string
+		data   []byte
+		format string
+	}{
+		{"base64", "data:image/png;base64,iVBORw0KGgo=", []byte("\x89PNG\r\n\x1a\n"), "png"},
+		{"base64 with whitespace", "data:image/png;base64,iVBO Rw0K\nGgo=", []byte("\x89PNG\r\n\x1a\n"), "png"},
+		{"url encoded", "data:image/svg+xml,%3Csvg%20xmlns%3D%22x%22%2F%3E", []byte(`<svg xmlns="x"/>`), "svg+xml"},
+		{"url encoded without escapes", "data:image/svg+xml,<svg/>", []byte("<svg/>"), "svg+xml"},
+		{"url encoded with charset", "data:image/svg+xml;charset=utf-8,%3Csvg%2F%3E", []byte("<svg/>"), "svg+xml"},
+		{"base64 with charset", "data:image/gif;charset=utf-8;base64,R0lGODlh", []byte("GIF89a"), "gif"},
+		{"upper case media type", "data:IMAGE/JPEG;base64,/9j/", []byte{0xff, 0xd8, 0xff}, "jpeg"},
+		{"comma in data", "data:text/plain,a,b", []byte("a,b"), "text/plain"},
+		{"no media type", "data:,hello", []byte("hello"), ""},
+		{"empty", "data:image/png;base64,", []byte{}, "png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, format := decodeDataURI(tt.uri)
+			if !bytes.Equal(data, tt.data) {
+				t.Errorf("decodeDataURI(%q) data = %q, want %q", tt.uri, data, tt.data)
+			}
+			if format != tt.format {
+				t.Errorf("decodeDataURI(%q) format = %q, want %q", tt.uri, format, tt.format)
+			}
+		})
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"jpg", []byte{0xff, 0xd8, 0xff, 0xe0, 0, 0x10}, "jpg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), "png"},
+		{"gif87a", []byte("GIF87a\x01\x00"), "gif"},
+		{"gif89a", []byte("GIF89a\x01\x00"), "gif"},
+		{"truncated jpg", []byte{0xff, 0xd8}, ""},
+		{"truncated png", []byte("\x89PNG"), ""},
+		{"truncated gif", []byte("GIF8"), ""},
+		{"svg", []byte("<svg/>"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if format := imageFormat(tt.data); format != tt.format {
+				t.Errorf("imageFormat(%q) = %q, want %q", tt.data, format, tt.format)
+			}
+		})
+	}
+}
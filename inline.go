@@ -0,0 +1,107 @@
+package pdfb
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// builtinIcons contains the icons built into Icon, drawn on a 24 by 24 grid in the colour of the text
+var builtinIcons = map[string]string{
+	"arrow-down":  `<path transform="rotate(90 12 12)" d="M4 11h11l-4-4 1.5-1.5L19 12l-6.5 6.5L11 17l4-4H4z"/>`,
+	"arrow-left":  `<path transform="rotate(180 12 12)" d="M4 11h11l-4-4 1.5-1.5L19 12l-6.5 6.5L11 17l4-4H4z"/>`,
+	"arrow-right": `<path d="M4 11h11l-4-4 1.5-1.5L19 12l-6.5 6.5L11 17l4-4H4z"/>`,
+	"arrow-up":    `<path transform="rotate(-90 12 12)" d="M4 11h11l-4-4 1.5-1.5L19 12l-6.5 6.5L11 17l4-4H4z"/>`,
+	"check":       `<path d="M3.5 12.5l2-2 4 4 9-9 2 2-11 11z"/>`,
+	"circle":      `<circle cx="12" cy="12" r="9"/>`,
+	"cross":       `<path d="M5 7l2-2 5 5 5-5 2 2-5 5 5 5-2 2-5-5-5 5-2-2 5-5z"/>`,
+	"dot":         `<circle cx="12" cy="12" r="4"/>`,
+	"flag":        `<path d="M5 2h2v20H5zM8 3h11l-3 5 3 5H8z"/>`,
+	"heart":       `<path d="M12 21l-1.5-1.3C5 15 2 12.2 2 8.5 2 5.5 4.4 3 7.5 3c1.7 0 3.4.8 4.5 2.1C13.1 3.8 14.8 3 16.5 3 19.6 3 22 5.5 22 8.5c0 3.7-3 6.5-8.5 11.2z"/>`,
+	"info":        `<path fill-rule="evenodd" d="M12 2a10 10 0 1 0 0 20a10 10 0 1 0 0-20zM11 10h2v7h-2zM11 6h2v2h-2z"/>`,
+	"minus":       `<path d="M4 10h16v4H4z"/>`,
+	"plus":        `<path d="M10 4h4v6h6v4h-6v6h-4v-6H4v-4h6z"/>`,
+	"square":      `<rect x="4" y="4" width="16" height="16"/>`,
+	"star":        `<polygon points="12,2 14.47,8.6 21.51,8.91 15.99,13.3 17.88,20.09 12,16.2 6.12,20.09 8.01,13.3 2.49,8.91 9.53,8.6"/>`,
+	"warning":     `<path fill-rule="evenodd" d="M12 2L23 21H1zM11 9h2v6h-2zM11 16.5h2v2h-2z"/>`,
+}
+
+// InlineImage is used to insert an image into flowing text, sitting on the
+// baseline of the text and wrapping onto the next line with it.
+// The image is scale times the font size tall, 0 is the same as 1.
+func (p *Pdfb) InlineImage(filename string, scale float64) {
+	// check if image exists
+	if !fileExists(filename) {
+		log.Fatalf("Image could not be located (%s)\n", filename)
+	}
+
+	img := p.readImageFile(filename)
+	h := p.inlineHeight(scale)
+	w := h * float64(img.width) / float64(img.height)
+
+	x, top := p.placeInline(w, h)
+	p.paintImage(img, ImageOptions{}, x, top, w, h)
+
+	p.checkpoint("Inline image printed")
+}
+
+// Icon is used to insert a named icon into flowing text, in the colour of
+// the text and sized in the same way as InlineImage. The built in icons are
+// arrow-down, arrow-left, arrow-right, arrow-up, check, circle, cross, dot,
+// flag, heart, info, minus, plus, square, star and warning, and more can be
+// added with RegisterIcon.
+func (p *Pdfb) Icon(name string, scale float64) {
+	svg, ok := p.icons[name]
+	if !ok {
+		shape, ok := builtinIcons[name]
+		if !ok {
+			log.Fatalf("Invalid icon name (%s)\n", name)
+		}
+		r, g, b := p.pdf.GetTextColor()
+		svg = fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24" fill="#%02x%02x%02x">%s</svg>`, r, g, b, shape)
+	}
+
+	h := p.inlineHeight(scale)
+	x, top := p.placeInline(h, h)
+	p.SVG(strings.NewReader(svg), x, top, h, h)
+
+	p.checkpoint("Icon printed")
+}
+
+// RegisterIcon is used to add an icon which can be inserted with Icon,
+// from an SVG image which is fitted into a square the size of the text.
+// Registering the name of a built in icon replaces it.
+func (p *Pdfb) RegisterIcon(name, svg string) {
+	p.icons[name] = svg
+	p.checkpoint("Icon registered")
+}
+
+// returns the height of an inline image scale times the font size
+func (p *Pdfb) inlineHeight(scale float64) float64 {
+	if scale <= 0 {
+		scale = 1
+	}
+	_, fontSize := p.pdf.GetFontSize()
+	return fontSize * scale
+}
+
+// moves the cursor past space for an inline image w by h, wrapping onto the
+// next line if it doesn't fit on this one. Returns the position to draw it
+// at, with the bottom of the image on the baseline of the text
+func (p *Pdfb) placeInline(w, h float64) (float64, float64) {
+	left, _, right, _ := p.pdf.GetMargins()
+	if p.GetX()+w > p.GetPageWidth()-right && p.GetX() > left {
+		p.pdf.Ln(p.lineHeight)
+		p.updateFloat()
+	}
+	p.fitOnPage(p.GetY(), p.lineHeight)
+	x := p.GetX()
+
+	// gofpdf puts the baseline of text 0.3 of the font size below the
+	// middle of the line
+	_, fontSize := p.pdf.GetFontSize()
+	baseline := p.GetY() + p.lineHeight/2 + fontSize*0.3
+
+	p.pdf.SetX(x + w)
+	return x, baseline - h
+}
@@ -0,0 +1,40 @@
+package pdfb
+
+// Layer is used to put everything drawn inside fn on a named layer, which
+// can be shown and hidden in PDF viewers. visible sets whether the layer is
+// shown when the document is opened, the first value given for a name is used.
+// Calling Layer again with the same name adds more content to the layer.
+//
+// Layers can't be nested, a layer started inside another is used in its
+// place until it ends.
+func (p *Pdfb) Layer(name string, visible bool, fn func()) {
+	id, ok := p.layers[name]
+	if !ok {
+		id = p.pdf.AddLayer(name, visible)
+		p.layers[name] = id
+
+		// show the list of layers when the document is opened
+		p.pdf.OpenLayerPane()
+	}
+
+	previous := p.layer
+	p.layer = id
+	p.pdf.BeginLayer(id)
+
+	fn()
+
+	p.layer = previous
+	if p.layer >= 0 {
+		p.pdf.BeginLayer(p.layer)
+	} else {
+		p.pdf.EndLayer()
+	}
+
+	p.checkpoint("Layer used")
+}
+
+// called when a page is finished, ends the layer in use so that it
+// can be started again on the next page by drawPageStart
+func (p *Pdfb) drawPageEnd() {
+	p.pdf.EndLayer()
+}
@@ -0,0 +1,83 @@
+package pdfb
+
+import (
+	"log"
+	"strings"
+)
+
+// names of the blend modes supported by gofpdf
+var blendModes = []string{
+	"Normal", "Multiply", "Screen", "Overlay", "Darken", "Lighten",
+	"ColorDodge", "ColorBurn", "HardLight", "SoftLight", "Difference",
+	"Exclusion", "Hue", "Saturation", "Color", "Luminosity",
+}
+
+// WithOpacity is used to draw everything inside fn, including text,
+// with the given opacity (0-1). Opacities given to shapes and images
+// inside fn are multiplied by it, and the previous opacity is put
+// back once fn returns.
+func (p *Pdfb) WithOpacity(opacity float64, fn func()) {
+	if opacity < 0 || opacity > 1 {
+		log.Fatalf("Invalid opacity, must be between 0 and 1 (%g)\n", opacity)
+	}
+
+	currentOpacity := p.opacity
+	p.opacity *= opacity
+	p.pdf.SetAlpha(p.opacity, p.blendMode)
+
+	fn()
+
+	p.opacity = currentOpacity
+	p.pdf.SetAlpha(p.opacity, p.blendMode)
+
+	p.checkpoint("Opacity used")
+}
+
+// WithBlendMode is used to draw everything inside fn, including text,
+// using a blend mode such as Multiply, Screen, Overlay, Darken or Lighten.
+// The previous blend mode is put back once fn returns.
+func (p *Pdfb) WithBlendMode(mode string, fn func()) {
+	currentMode := p.blendMode
+	p.blendMode = blendModeName(mode)
+	p.pdf.SetAlpha(p.opacity, p.blendMode)
+
+	fn()
+
+	p.blendMode = currentMode
+	p.pdf.SetAlpha(p.opacity, p.blendMode)
+
+	p.checkpoint("Blend mode used")
+}
+
+// sets the opacity and blend mode used for drawing, combined with those
+// set by WithOpacity and WithBlendMode. An empty mode keeps the current one.
+func (p *Pdfb) setAlpha(opacity float64, mode string) {
+	if mode == "" {
+		mode = p.blendMode
+	}
+	p.pdf.SetAlpha(p.opacity*opacity, blendModeName(mode))
+}
+
+// returns the gofpdf name of a blend mode, ignoring case, spaces and dashes
+// eg. "color-dodge" is ColorDodge
+func blendModeName(mode string) string {
+	name := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(mode)
+	if name == "" {
+		return "Normal"
+	}
+	for _, m := range blendModes {
+		if strings.EqualFold(m, name) {
+			return m
+		}
+	}
+	log.Fatalf("Invalid blend mode (%s)\n", mode)
+	return ""
+}
+
+// returns an opacity to use for drawing, with 0 treated as fully opaque
+func drawOpacity(opacity float64) float64 {
+	if opacity <= 0 || opacity > 1 {
+		return 1
+	}
+	return opacity
+}
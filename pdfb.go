@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -19,30 +20,53 @@ type Pdfb struct {
 	pdf *gofpdf.Fpdf
 
 	bgFunc          func()
+	blendMode       string
+	column          int
+	figures         []FigureInfo
+	float           *imageFloat
+	fontCoverage    map[string]runeSet
+	fontFaces       map[string][]fontFace
+	fontSet         bool
 	footerHeight    float64
 	headerHeight    float64
+	headersDrawn    bool
 	headings        []heading
+	hiddenFooters   map[int]bool
+	hiddenHeaders   map[int]bool
+	icons           map[string]string
+	imageBytesSaved int
+	layer           int
+	layers          map[string]int
+	newSection      bool
+	opacity         float64
+	pages           map[int]*pageInfo
+	systemFonts     []systemFont
+	template        string
+	templates       map[string]*PageTemplate
 	tocPage         int
 	writingContents bool
 
 	// customisable
-	accentColour     string
-	author           string
-	background       string
-	creationDate     time.Time
-	font             Font
-	foreground       string
-	indentSize       float64
-	keywords         []string
-	lineHeight       float64
-	margin           float64
-	modificationDate time.Time
-	orientation      string
-	pageHeight       float64
-	pageSize         string
-	pageWidth        float64
-	subject          string
-	title            string
+	accentColour       string
+	author             string
+	background         string
+	backgroundGradient *Gradient
+	creationDate       time.Time
+	font               Font
+	foreground         string
+	imagePolicy        ImagePolicy
+	indentSize         float64
+	keywords           []string
+	lineHeight         float64
+	margin             float64
+	modificationDate   time.Time
+	orientation        string
+	pageHeight         float64
+	pageSize           string
+	pageWidth          float64
+	subject            string
+	title              string
+	watermark          Watermark
 }
 
 // New returns a PDF Builder
@@ -52,39 +76,54 @@ func New() *Pdfb {
 		pdf: gofpdf.New("P", "mm", "A4", ""),
 
 		bgFunc:          func() {},
+		blendMode:       "Normal",
+		column:          0,
+		figures:         []FigureInfo{},
+		float:           nil,
+		fontCoverage:    map[string]runeSet{},
+		fontFaces:       map[string][]fontFace{},
+		fontSet:         false,
 		footerHeight:    0,
 		headerHeight:    0,
+		headersDrawn:    false,
 		headings:        []heading{},
+		hiddenFooters:   map[int]bool{},
+		hiddenHeaders:   map[int]bool{},
+		icons:           map[string]string{},
+		imageBytesSaved: 0,
+		layer:           -1,
+		layers:          map[string]int{},
+		newSection:      true,
+		opacity:         1,
+		pages:           map[int]*pageInfo{},
+		systemFonts:     nil,
+		template:        "default",
+		templates:       map[string]*PageTemplate{"default": {}},
 		tocPage:         -1,
 		writingContents: false,
 
-		accentColour:     "#f00",
-		author:           "",
-		background:       "#ffffff",
-		creationDate:     time.Now(),
-		font:             Font{Family: "Arial", Size: 12.0},
-		foreground:       "#000000",
-		indentSize:       4,
-		keywords:         []string{},
-		lineHeight:       6.0,
-		margin:           20.0,
-		modificationDate: time.Now(),
-		orientation:      "P",
-		pageHeight:       297.0,
-		pageSize:         "A4",
-		pageWidth:        210.0,
-		subject:          "",
-		title:            "",
+		accentColour:       "#f00",
+		author:             "",
+		background:         "#ffffff",
+		backgroundGradient: nil,
+		creationDate:       time.Now(),
+		font:               Font{Family: DefaultFont, Size: 12.0},
+		foreground:         "#000000",
+		imagePolicy:        ImagePolicy{},
+		indentSize:         4,
+		keywords:           []string{},
+		lineHeight:         6.0,
+		margin:             20.0,
+		modificationDate:   time.Now(),
+		orientation:        "P",
+		pageHeight:         297.0,
+		pageSize:           "A4",
+		pageWidth:          210.0,
+		subject:            "",
+		title:              "",
+		watermark:          Watermark{},
 	}
 
-	// import inter to be used as the default font
-	// p.pdf.AddUTF8FontFromBytes("Inter", "", decode(inter.InterRegular))
-	// p.pdf.AddUTF8FontFromBytes("Inter", "b", decode(inter.InterBold))
-	// p.pdf.AddUTF8FontFromBytes("Inter", "i", decode(inter.InterItalic))
-	// p.pdf.AddUTF8FontFromBytes("Inter", "bi", decode(inter.InterBoldItalic))
-
-	// p.checkpoint("Inter font imported")
-
 	// pdf initialisation
 	p.pdf.SetCellMargin(0)
 	p.pdf.SetProducer("GoFPDF 2.17.2", true)
@@ -93,7 +132,6 @@ func New() *Pdfb {
 	p.pdf.SetAutoPageBreak(true, p.margin)
 	p.pdf.SetCreator("github.com/vqvw/pdfb", true)
 	p.pdf.SetCreationDate(p.creationDate)
-	p.pdf.SetFont(p.font.Family, "", p.font.Size)
 	p.pdf.SetFontSize(p.font.Size)
 	p.pdf.SetKeywords(strings.Join(p.keywords, ";"), true)
 	p.pdf.SetMargins(p.margin, p.margin, p.margin)
@@ -106,17 +144,17 @@ func New() *Pdfb {
 
 	// bgFunc gets called in headerFunc, used to set the background
 	// colour of the document
-	p.bgFunc = func() {
-		// w, h, _ := p.pdf.PageSize(p.pdf.PageNo())
-		currentR, currentG, currentB := p.pdf.GetFillColor()
-		// p.Box(0, 0, w, h, p.background, true, false)
-		p.pdf.SetFillColor(currentR, currentG, currentB)
-	}
+	p.bgFunc = p.drawBackground
 
-	// default header, does nothing except set the background colour
-	p.pdf.SetHeaderFunc(func() {
-		p.bgFunc()
-	})
+	// sets up each new page using the page template in use, the header
+	// and footer themselves are drawn once every page has been added
+	p.pdf.SetHeaderFunc(p.drawPageStart)
+
+	// layers have to be ended at the end of each page and started again on the next
+	p.pdf.SetFooterFunc(p.drawPageEnd)
+
+	// used to flow text into the next column of multi-column templates
+	p.pdf.SetAcceptPageBreakFunc(p.acceptPageBreak)
 
 	return p
 }
@@ -153,6 +191,17 @@ func (p *Pdfb) GetBackground() string {
 	return p.background
 }
 
+// SetBackgroundGradient is used to set a gradient to draw as the background
+// in place of the background colour, nil goes back to the background colour
+func (p *Pdfb) SetBackgroundGradient(backgroundGradient *Gradient) {
+	p.backgroundGradient = backgroundGradient
+}
+
+// GetBackgroundGradient is used to get the backgroundGradient
+func (p *Pdfb) GetBackgroundGradient() *Gradient {
+	return p.backgroundGradient
+}
+
 // SetCreationDate is used to set the creationDate
 func (p *Pdfb) SetCreationDate(creationDate time.Time) {
 	p.creationDate = creationDate
@@ -165,6 +214,19 @@ func (p *Pdfb) GetCreationDate() time.Time {
 	return p.creationDate
 }
 
+// SetImagePolicy is used to set how images are prepared before they are
+// embedded, eg. downsampling photos to 150 DPI to make documents smaller
+// The policy is used for images inserted after it is set.
+func (p *Pdfb) SetImagePolicy(imagePolicy ImagePolicy) {
+	p.imagePolicy = imagePolicy
+	p.checkpoint("Image policy set")
+}
+
+// GetImagePolicy is used to get the image policy
+func (p *Pdfb) GetImagePolicy() ImagePolicy {
+	return p.imagePolicy
+}
+
 // SetIndentSize is used to set the indentSize
 func (p *Pdfb) SetIndentSize(indentSize float64) {
 	p.indentSize = indentSize
@@ -200,7 +262,7 @@ func (p *Pdfb) GetLineHeight() float64 {
 // SetMargin is used to set the margin
 func (p *Pdfb) SetMargin(margin float64) {
 	p.margin = margin
-	p.pdf.SetMargins(margin, margin, margin)
+	p.applyTemplate(p.currentTemplate())
 	p.checkpoint("Margins set")
 }
 
@@ -328,6 +390,18 @@ func (p *Pdfb) GetTitle() string {
 	return p.title
 }
 
+// SetWatermark is used to set the watermark
+// The watermark is drawn from the next page onwards.
+func (p *Pdfb) SetWatermark(watermark Watermark) {
+	p.watermark = watermark
+	p.checkpoint("Watermark set")
+}
+
+// GetWatermark is used to get the watermark
+func (p *Pdfb) GetWatermark() Watermark {
+	return p.watermark
+}
+
 //
 //	End of setters and getters
 //
@@ -355,121 +429,28 @@ func (p *Pdfb) Page() {
 	p.checkpoint("Page added")
 }
 
-// SetHeader is used to set the header
+// SetHeader is used to set the header of the page template in use
+// The header is used from the next page onwards.
 func (p *Pdfb) SetHeader(fontFamily string, content ...TextAlign) {
-	pageWidth, _, _ := p.pdf.PageSize(p.pdf.PageNo())
-	sectionWidth := (pageWidth - p.margin*2) / float64(len(content))
-	p.headerHeight = 25.0
-
-	p.pdf.SetHeaderFunc(func() {
-		// copy the current font
-		currentFont := p.fontCopy(p.font)
-
-		// get current foreground
-		currentFG := p.foreground
-
-		// used to draw the background colour
-		p.bgFunc()
-
-		// put the header at the top of the page
-		p.SetY(0)
-
-		// set font for header text
-		p.SetFont(Font{
-			Family: fontFamily,
-			Size:   12,
-		})
-
-		// set foreground
-		p.SetForeground("#000")
-
-		// create cells for each section
-		for _, c := range content {
-			p.pdf.CellFormat(sectionWidth, p.headerHeight, c.Text, "", 0, "M"+p.makeAlignStr(c.Align), false, 0, "")
-		}
-
-		// set the font back to how it was
-		p.SetFont(currentFont)
-
-		// set foreground back to how it was
-		p.SetForeground(currentFG)
-
-		// set cursor to the bottom of the header
-		p.pdf.SetX(p.margin)
-		p.pdf.SetY(p.headerHeight)
-
-		p.checkpoint("Header printed")
-	})
+	t := p.currentTemplate()
+	t.HeaderFont = fontFamily
+	t.Header = content
+	p.applyTemplate(t)
 
 	p.checkpoint("Header set")
 }
 
-// SetFooter is used to set the footer
+// SetFooter is used to set the footer of the page template in use
+// The footer is used from the next page onwards.
 // The page number and number of pages can be used in the footer
-// using {pages} and {pages}.
+// using {page} and {pages}.
 //
 // Eg. "Page {page} of {pages}"
 func (p *Pdfb) SetFooter(fontFamily string, content ...TextAlign) {
-	pageWidth, pageHeight, _ := p.pdf.PageSize(p.pdf.PageNo())
-	sectionWidth := (pageWidth - p.margin*2) / float64(len(content))
-	p.footerHeight = 25.0
-
-	triggeredPage := p.pdf.PageNo()
-
-	p.pdf.SetFooterFunc(func() {
-		// don't run on the page that SetFooter was called on, in order to match the behaviour of SetHeader
-		if p.pdf.PageNo() == triggeredPage {
-			return
-		}
-
-		// copy the current font
-		currentFont := p.fontCopy(p.font)
-
-		// get current foreground
-		currentFG := p.foreground
-
-		// set cursor to the position where the top of the footer starts drawing
-		p.SetY(pageHeight - p.footerHeight)
-
-		// set font for header text
-		p.SetFont(Font{
-			Family: fontFamily,
-			Size:   12,
-		})
-
-		// set foreground
-		p.SetForeground("#000")
-
-		// create cells for each section
-		for _, c := range content {
-			// deal with offset caused by width of text being calculated
-			// with the {page} and {pages} aliases (resulting text is shorter)
-			var offset float64
-			if strings.Contains(c.Text, "{page}") {
-				c.Text = strings.ReplaceAll(c.Text, "{page}", strconv.Itoa(p.pdf.PageNo()))
-				offset += p.pdf.GetStringWidth("{page}")
-			}
-			if strings.Contains(c.Text, "{pages}") {
-				offset += p.pdf.GetStringWidth("{pages}")
-			}
-			offset /= 2
-			offset -= p.pdf.GetStringWidth("00") / 2
-			p.SetX(p.GetX() + offset)
-			// print
-			p.pdf.CellFormat(sectionWidth-offset, p.footerHeight, c.Text, "", 0, "M"+p.makeAlignStr(c.Align), false, 0, "")
-		}
-
-		// set the font back to how it was
-		p.SetFont(currentFont)
-
-		// set foreground back to how it was
-		p.SetForeground(currentFG)
-
-		p.checkpoint("Footer printed")
-	})
-
-	// set the space from the bottom where the auto page break gets triggered
-	p.pdf.SetAutoPageBreak(true, p.footerHeight)
+	t := p.currentTemplate()
+	t.FooterFont = fontFamily
+	t.Footer = content
+	p.applyTemplate(t)
 
 	p.checkpoint("Footer set")
 }
@@ -497,27 +478,19 @@ func (p *Pdfb) GetY() float64 {
 }
 
 // Box is used to draw a box
-func (p *Pdfb) Box(x, y, w, h float64, hex string, fill, border bool) {
-	var styleStr string
-
-	if fill {
-		styleStr += "F"
-	}
-	if border {
-		styleStr += "D"
-	}
-
-	p.pdf.SetFillColor(255, 0, 0)
-	p.pdf.Rect(x, y, w, h, styleStr)
+func (p *Pdfb) Box(x, y, w, h float64, fill Fill, stroke Stroke) {
+	p.drawShape(x, y, w, h, fill, stroke, func() {
+		p.rectPath(x, y, w, h)
+	})
 
 	p.checkpoint("Box created")
 }
 
 // BoxInline is used to draw a box inline
-func (p *Pdfb) BoxInline(w, h float64, hex string, fill, border bool) {
+func (p *Pdfb) BoxInline(w, h float64, fill Fill, stroke Stroke) {
 	pageWidth := p.GetPageWidth() - p.margin*2
 	currentX, currentY := p.GetX(), p.GetY()
-	p.Box(currentX, currentY, w, h, hex, fill, border)
+	p.Box(currentX, currentY, w, h, fill, stroke)
 	if currentX+w < pageWidth {
 		p.SetX(currentX + w)
 	} else {
@@ -527,33 +500,20 @@ func (p *Pdfb) BoxInline(w, h float64, hex string, fill, border bool) {
 }
 
 // Circle is used to draw a circle
-func (p *Pdfb) Circle(x, y, radius float64, hex string, fill, border bool) {
-	var styleStr string
-
-	if fill {
-		styleStr += "F"
-	}
-	if border {
-		styleStr += "D"
-	}
-
-	p.pdf.SetFillColor(255, 225, 225)
-	p.pdf.Circle(x, y, radius, styleStr)
+func (p *Pdfb) Circle(x, y, radius float64, fill Fill, stroke Stroke) {
+	p.drawShape(x-radius, y-radius, radius*2, radius*2, fill, stroke, func() {
+		p.ellipsePath(x, y, radius, radius)
+	})
 
 	p.checkpoint("Circle created")
 }
 
 // Line is used to draw lines from one point to another
-func (p *Pdfb) Line(fromX, fromY, toX, toY float64, hex string, weight float64) {
-	currentDrawR, currentDrawG, currentDrawB := p.pdf.GetDrawColor()
-	currentWeight := p.pdf.GetLineWidth()
-
-	p.pdf.SetDrawColor(255, 0, 0)
-	p.pdf.SetLineWidth(weight)
-	p.pdf.Line(fromX, fromY, toX, toY)
-
-	p.pdf.SetDrawColor(currentDrawR, currentDrawG, currentDrawB)
-	p.pdf.SetLineWidth(currentWeight)
+func (p *Pdfb) Line(fromX, fromY, toX, toY float64, stroke Stroke) {
+	p.drawShape(math.Min(fromX, toX), math.Min(fromY, toY), math.Abs(toX-fromX), math.Abs(toY-fromY), Fill{}, stroke.orDefault(), func() {
+		p.pdf.MoveTo(fromX, fromY)
+		p.pdf.LineTo(toX, toY)
+	})
 
 	p.checkpoint("Line created")
 }
@@ -570,6 +530,7 @@ func (p *Pdfb) SetLine(hex string, weight float64) {
 func (p *Pdfb) Ln(lines int) {
 	for i := 0; i < lines; i++ {
 		p.pdf.Ln(p.lineHeight)
+		p.updateFloat()
 	}
 	p.checkpoint("Line break inserted")
 }
@@ -577,7 +538,12 @@ func (p *Pdfb) Ln(lines int) {
 // Write is used to write text to the page
 func (p *Pdfb) Write(format string, a ...interface{}) {
 	text := fmt.Sprintf(format, a...)
-	p.pdf.Write(p.lineHeight, text)
+	if p.float != nil {
+		text = p.writeAroundFloat(text)
+	}
+	if text != "" {
+		p.writeText(text, "")
+	}
 	p.checkpoint("Text written")
 }
 
@@ -617,6 +583,9 @@ func (p *Pdfb) Heading(level int, str string) {
 		log.Fatalf("Invalid level supplied to Heading (%d)\n", level)
 	}
 
+	// headings start below any floating image
+	p.clearFloat()
+
 	// create heading link
 	headingLink := p.pdf.AddLink()
 	p.pdf.SetLink(headingLink, p.GetY(), p.pdf.PageNo())
@@ -684,7 +653,7 @@ func (p *Pdfb) Heading(level int, str string) {
 
 	// draw line under for heading level 1
 	if level == 1 {
-		p.Line(p.margin, p.GetY(), p.GetPageWidth()-p.margin, p.GetY(), p.accentColour, 0.5)
+		p.Line(p.margin, p.GetY(), p.GetPageWidth()-p.margin, p.GetY(), Stroke{Colour: p.accentColour, Width: 0.5})
 		p.SetY(p.GetY() + p.lineHeight*0.25) // larger gap below heading due to line
 	} else {
 		p.SetY(p.GetY() + p.lineHeight*0.1) // gap below heading
@@ -722,6 +691,9 @@ func (p *Pdfb) List(items []ListItem) {
 	currentFont := p.fontCopy(p.font)
 	maxIndent := 10
 
+	// lists start below any floating image
+	p.clearFloat()
+
 	// loop through list items
 	for _, item := range items {
 		// indent in from margin (indents stop at level 8)
@@ -765,7 +737,7 @@ func (p *Pdfb) List(items []ListItem) {
 		p.SetFont(currentFont)
 
 		// print
-		p.pdf.MultiCell(0, p.lineHeight, item.Text, "", "", false)
+		p.multiCellText(0, p.lineHeight, item.Text, "")
 
 		// leave some space under each list item
 		p.SetY(p.GetY() + 2)
@@ -774,42 +746,6 @@ func (p *Pdfb) List(items []ListItem) {
 	p.checkpoint("List printed")
 }
 
-// Image is used to insert an image
-// Use 0 in place of w or h to keep the aspect ratio
-func (p *Pdfb) Image(filename, align string, x, y, w, h float64) {
-	// check if image exists
-	if !fileExists(filename) {
-		log.Fatalf("Image could not be located (%s)\n", filename)
-	}
-
-	// calc w and/or h values if 0 is given
-	if w == 0 {
-		info := p.pdf.RegisterImage(filename, "")
-		w = h * info.Width() / info.Height()
-	}
-	if h == 0 {
-		info := p.pdf.RegisterImage(filename, "")
-		h = w * info.Height() / info.Width()
-	}
-
-	// align image for left, right, or centre
-	align = strings.ToLower(align)
-	switch {
-	case align == "l" || align == "left" || align == "":
-	case align == "c" || align == "centre":
-		x = p.GetX() + (p.GetPageWidth()-p.margin*2)/2 - w/2
-	case align == "r" || align == "right":
-		x = p.GetPageWidth() - p.margin - w
-	default:
-		log.Fatalf("Invalid alignment supplied to Image (%s)\n", align)
-	}
-
-	// draw image
-	p.pdf.Image(filename, x, y, w, h, true, "", 0, "")
-
-	p.checkpoint("Image printed")
-}
-
 // Debug is used for debugging purposes
 func (p *Pdfb) Debug(str string) {
 	fmt.Println("-- Debug:", str)
@@ -820,7 +756,7 @@ func (p *Pdfb) Hyperlink(displayText, url string) {
 	currentFG := p.GetForeground()
 
 	p.SetForeground("#00f")
-	p.pdf.WriteLinkString(p.lineHeight, displayText, url)
+	p.writeText(displayText, url)
 
 	p.SetForeground(currentFG)
 
@@ -874,7 +810,7 @@ func (p *Pdfb) finalFunc() {
 			headingIndent := p.indentSize * float64(heading.level-1)
 
 			// heading text
-			headingTextWidth := headingIndent + p.pdf.GetStringWidth(heading.text)
+			headingTextWidth := headingIndent + p.stringWidth(heading.text)
 
 			// heading page
 			headingPage := strconv.Itoa(heading.page)
@@ -902,7 +838,7 @@ func (p *Pdfb) finalFunc() {
 
 			// heading text
 			p.SetX(p.margin + headingIndent)
-			p.pdf.CellFormat(headingTextWidth-headingIndent, p.lineHeight, heading.text, "", 0, "L", false, heading.link, "")
+			p.cellText(headingTextWidth-headingIndent, p.lineHeight, heading.text, "L", heading.link)
 
 			// dots
 			p.pdf.CellFormat(dotSpace, p.lineHeight, dots, "", 0, "C", false, 0, "")
@@ -915,6 +851,10 @@ func (p *Pdfb) finalFunc() {
 		// go back to the end of the document before output
 		p.pdf.SetPage(p.pdf.PageCount())
 	}
+
+	// go back and write the headers, footers and watermarks of every page
+	p.drawHeadersFooters()
+
 	p.checkpoint("Final func used")
 }
 
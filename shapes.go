@@ -0,0 +1,482 @@
+package pdfb
+
+import (
+	"log"
+	"math"
+	"strings"
+)
+
+// Fill defines how the inside of a shape is painted
+//
+// Colour is a hex colour, Opacity goes from 0 to 1 with 0 treated as fully
+// opaque, BlendMode is one such as Multiply or Screen (see WithBlendMode),
+// and Gradient is painted in place of Colour when it is set.
+// Shapes given an empty Fill are not filled.
+type Fill struct {
+	Colour    string
+	Opacity   float64
+	BlendMode string
+	Gradient  *Gradient
+}
+
+// Stroke defines how the outline of a shape is drawn
+//
+// Colour defaults to the foreground colour and Width to 0.2mm,
+// Dash is a pattern of dash and gap lengths eg. []float64{2, 1},
+// Cap is butt, round or square and Join is mitre, round or bevel.
+// Opacity and BlendMode work in the same way as they do for Fill.
+// Shapes given an empty Stroke have no outline, apart from lines,
+// arcs, curves and arrows which are drawn using the defaults.
+type Stroke struct {
+	Colour    string
+	Width     float64
+	Dash      []float64
+	Cap       string
+	Join      string
+	Opacity   float64
+	BlendMode string
+}
+
+// Corners defines the radius of each corner of a RoundedBox
+type Corners struct {
+	TopLeft     float64
+	TopRight    float64
+	BottomRight float64
+	BottomLeft  float64
+}
+
+// Point is a position on the page
+type Point struct {
+	X float64
+	Y float64
+}
+
+// ArrowHeads defines the heads drawn at each end of an arrow
+//
+// Start and End are none, open or closed, End defaults to closed.
+// Length and Width are the size of the heads, which default
+// to a size to suit the width of the stroke.
+type ArrowHeads struct {
+	Start  string
+	End    string
+	Length float64
+	Width  float64
+}
+
+// Path is used to build a shape out of straight lines and curves,
+// which is drawn using Pdfb.Path
+type Path struct {
+	segments []pathSegment
+}
+
+// pathSegment is one command of a path, using only M, L, C and Z
+type pathSegment struct {
+	op  byte
+	pts []float64
+}
+
+// NewPath returns an empty path
+func NewPath() *Path {
+	return &Path{}
+}
+
+// MoveTo is used to start a new part of the path at the given point
+func (path *Path) MoveTo(x, y float64) *Path {
+	path.segments = append(path.segments, pathSegment{'M', []float64{x, y}})
+	return path
+}
+
+// LineTo is used to add a straight line to the given point
+func (path *Path) LineTo(x, y float64) *Path {
+	path.segments = append(path.segments, pathSegment{'L', []float64{x, y}})
+	return path
+}
+
+// CurveTo is used to add a cubic bezier curve to the given point,
+// using the control points (cx1, cy1) and (cx2, cy2)
+func (path *Path) CurveTo(cx1, cy1, cx2, cy2, x, y float64) *Path {
+	path.segments = append(path.segments, pathSegment{'C', []float64{cx1, cy1, cx2, cy2, x, y}})
+	return path
+}
+
+// Close is used to draw a straight line back to the start of the current part of the path
+func (path *Path) Close() *Path {
+	path.segments = append(path.segments, pathSegment{op: 'Z'})
+	return path
+}
+
+// Path is used to draw a path built with NewPath
+func (p *Pdfb) Path(path *Path, fill Fill, stroke Stroke) {
+	if len(path.segments) == 0 || path.segments[0].op != 'M' {
+		log.Fatalln("Paths must start with MoveTo")
+	}
+
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, s := range path.segments {
+		for i := 0; i+1 < len(s.pts); i += 2 {
+			minX, maxX = math.Min(minX, s.pts[i]), math.Max(maxX, s.pts[i])
+			minY, maxY = math.Min(minY, s.pts[i+1]), math.Max(maxY, s.pts[i+1])
+		}
+	}
+
+	p.drawShape(minX, minY, maxX-minX, maxY-minY, fill, stroke, func() {
+		for _, s := range path.segments {
+			switch s.op {
+			case 'M':
+				p.pdf.MoveTo(s.pts[0], s.pts[1])
+			case 'L':
+				p.pdf.LineTo(s.pts[0], s.pts[1])
+			case 'C':
+				p.pdf.CurveBezierCubicTo(s.pts[0], s.pts[1], s.pts[2], s.pts[3], s.pts[4], s.pts[5])
+			case 'Z':
+				p.pdf.ClosePath()
+			}
+		}
+	})
+
+	p.checkpoint("Path created")
+}
+
+// RoundedBox is used to draw a box with rounded corners,
+// each corner can be given a different radius
+func (p *Pdfb) RoundedBox(x, y, w, h float64, corners Corners, fill Fill, stroke Stroke) {
+	p.drawShape(x, y, w, h, fill, stroke, func() {
+		p.roundedRectPath(x, y, w, h, corners)
+	})
+
+	p.checkpoint("Rounded box created")
+}
+
+// Ellipse is used to draw an ellipse centred on x, y
+func (p *Pdfb) Ellipse(x, y, rx, ry float64, fill Fill, stroke Stroke) {
+	p.drawShape(x-rx, y-ry, rx*2, ry*2, fill, stroke, func() {
+		p.ellipsePath(x, y, rx, ry)
+	})
+
+	p.checkpoint("Ellipse created")
+}
+
+// Polygon is used to draw a closed shape with straight sides between the points
+func (p *Pdfb) Polygon(points []Point, fill Fill, stroke Stroke) {
+	if len(points) < 3 {
+		log.Fatalf("Polygons must have at least 3 points (%d given)\n", len(points))
+	}
+
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, pt := range points {
+		minX, maxX = math.Min(minX, pt.X), math.Max(maxX, pt.X)
+		minY, maxY = math.Min(minY, pt.Y), math.Max(maxY, pt.Y)
+	}
+
+	p.drawShape(minX, minY, maxX-minX, maxY-minY, fill, stroke, func() {
+		p.polygonPath(points)
+	})
+
+	p.checkpoint("Polygon created")
+}
+
+// Arc is used to draw part of an ellipse centred on x, y, from one angle to
+// another in degrees counter-clockwise from the 3 o'clock position.
+// Filled arcs are closed through the centre, like a slice of a pie chart.
+func (p *Pdfb) Arc(x, y, rx, ry, from, to float64, fill Fill, stroke Stroke) {
+	if !fill.visible() {
+		stroke = stroke.orDefault()
+	}
+
+	p.drawShape(x-rx, y-ry, rx*2, ry*2, fill, stroke, func() {
+		a := from * math.Pi / 180
+		p.pdf.MoveTo(x+rx*math.Cos(a), y-ry*math.Sin(a))
+		p.arcTo(x, y, rx, ry, from, to)
+		if fill.visible() {
+			p.pdf.LineTo(x, y)
+			p.pdf.ClosePath()
+		}
+	})
+
+	p.checkpoint("Arc created")
+}
+
+// Bezier is used to draw a cubic bezier curve between two points, using two control points
+// Filled curves are closed with a straight line back to the start.
+func (p *Pdfb) Bezier(from, control1, control2, to Point, fill Fill, stroke Stroke) {
+	if !fill.visible() {
+		stroke = stroke.orDefault()
+	}
+
+	minX := math.Min(math.Min(from.X, control1.X), math.Min(control2.X, to.X))
+	minY := math.Min(math.Min(from.Y, control1.Y), math.Min(control2.Y, to.Y))
+	maxX := math.Max(math.Max(from.X, control1.X), math.Max(control2.X, to.X))
+	maxY := math.Max(math.Max(from.Y, control1.Y), math.Max(control2.Y, to.Y))
+
+	p.drawShape(minX, minY, maxX-minX, maxY-minY, fill, stroke, func() {
+		p.pdf.MoveTo(from.X, from.Y)
+		p.pdf.CurveBezierCubicTo(control1.X, control1.Y, control2.X, control2.Y, to.X, to.Y)
+		if fill.visible() {
+			p.pdf.ClosePath()
+		}
+	})
+
+	p.checkpoint("Bezier curve created")
+}
+
+// Arrow is used to draw a line with an arrow head at either or both ends
+func (p *Pdfb) Arrow(from, to Point, heads ArrowHeads, stroke Stroke) {
+	stroke = stroke.orDefault()
+	width := stroke.width()
+
+	if heads.End == "" {
+		heads.End = "closed"
+	}
+	if heads.Length <= 0 {
+		heads.Length = 2.5 + width*3
+	}
+	if heads.Width <= 0 {
+		heads.Width = heads.Length * 0.8
+	}
+
+	length := math.Hypot(to.X-from.X, to.Y-from.Y)
+	if length == 0 {
+		log.Fatalln("Arrows must start and end at different points")
+	}
+	// direction of the arrow, and at right angles to it
+	ux, uy := (to.X-from.X)/length, (to.Y-from.Y)/length
+	nx, ny := -uy, ux
+
+	// closed heads cover the end of the line, so stop the line short of
+	// the tip to keep it from poking through
+	start, end := from, to
+	if strings.ToLower(heads.Start) == "closed" {
+		start = Point{from.X + ux*heads.Length*0.9, from.Y + uy*heads.Length*0.9}
+	}
+	if strings.ToLower(heads.End) == "closed" {
+		end = Point{to.X - ux*heads.Length*0.9, to.Y - uy*heads.Length*0.9}
+	}
+	p.drawShape(math.Min(start.X, end.X), math.Min(start.Y, end.Y), math.Abs(end.X-start.X), math.Abs(end.Y-start.Y), Fill{}, stroke, func() {
+		p.pdf.MoveTo(start.X, start.Y)
+		p.pdf.LineTo(end.X, end.Y)
+	})
+
+	// draws a head with its tip at the given point, pointing along (dx, dy)
+	drawHead := func(style string, tip Point, dx, dy float64) {
+		base := Point{tip.X - dx*heads.Length, tip.Y - dy*heads.Length}
+		points := []Point{
+			{base.X + nx*heads.Width/2, base.Y + ny*heads.Width/2},
+			tip,
+			{base.X - nx*heads.Width/2, base.Y - ny*heads.Width/2},
+		}
+
+		switch strings.ToLower(style) {
+		case "none":
+		case "open":
+			headStroke := stroke
+			headStroke.Dash = nil
+			p.drawShape(tip.X, tip.Y, 0, 0, Fill{}, headStroke, func() {
+				p.pdf.MoveTo(points[0].X, points[0].Y)
+				p.pdf.LineTo(points[1].X, points[1].Y)
+				p.pdf.LineTo(points[2].X, points[2].Y)
+			})
+		case "closed":
+			p.drawShape(tip.X, tip.Y, 0, 0, Fill{Colour: stroke.colour(p), Opacity: stroke.Opacity, BlendMode: stroke.BlendMode}, Stroke{}, func() {
+				p.pdf.MoveTo(points[0].X, points[0].Y)
+				p.pdf.LineTo(points[1].X, points[1].Y)
+				p.pdf.LineTo(points[2].X, points[2].Y)
+				p.pdf.ClosePath()
+			})
+		default:
+			log.Fatalf("Invalid arrow head (%s)\n", style)
+		}
+	}
+	drawHead(heads.Start, from, -ux, -uy)
+	drawHead(heads.End, to, ux, uy)
+
+	p.checkpoint("Arrow created")
+}
+
+// reports whether the fill paints anything
+func (f Fill) visible() bool {
+	return f.Colour != "" || f.Gradient != nil
+}
+
+// reports whether the stroke draws anything
+func (s Stroke) visible() bool {
+	return s.Colour != "" || s.Width > 0
+}
+
+// returns the stroke, using the default width if it wouldn't draw anything
+func (s Stroke) orDefault() Stroke {
+	if !s.visible() {
+		s.Width = s.width()
+	}
+	return s
+}
+
+// returns the width of the stroke, 0.2mm if it isn't set
+func (s Stroke) width() float64 {
+	if s.Width > 0 {
+		return s.Width
+	}
+	return 0.2
+}
+
+// returns the colour of the stroke, the foreground colour if it isn't set
+func (s Stroke) colour(p *Pdfb) string {
+	if s.Colour != "" {
+		return s.Colour
+	}
+	return p.foreground
+}
+
+// draws a shape, path adds the outline of the shape to the current path
+// and x, y, w, h are the bounds of the shape which gradients are sized to
+func (p *Pdfb) drawShape(x, y, w, h float64, fill Fill, stroke Stroke, path func()) {
+	// building a path moves the cursor in gofpdf, so keep it to put back afterwards
+	currentX, currentY := p.pdf.GetXY()
+	currentFillR, currentFillG, currentFillB := p.pdf.GetFillColor()
+	currentDrawR, currentDrawG, currentDrawB := p.pdf.GetDrawColor()
+	currentWeight := p.pdf.GetLineWidth()
+	currentAlpha, currentBlend := p.pdf.GetAlpha()
+
+	if fill.visible() {
+		transparent := drawOpacity(fill.Opacity) < 1 || fill.BlendMode != ""
+		if transparent {
+			p.setAlpha(drawOpacity(fill.Opacity), fill.BlendMode)
+		}
+
+		if fill.Gradient != nil {
+			// clip to the outline of the shape and paint the gradient over its bounds
+			p.pdf.RawWriteStr("q")
+			path()
+			p.pdf.RawWriteStr("W n")
+			p.paintGradient(fill.Gradient, x, y, w, h)
+			p.pdf.RawWriteStr("Q")
+		} else {
+			p.pdf.SetFillColor(hexToRGB(fill.Colour))
+			path()
+			p.pdf.DrawPath("F")
+		}
+
+		if transparent {
+			p.pdf.SetAlpha(currentAlpha, currentBlend)
+		}
+	}
+
+	if stroke.visible() {
+		transparent := drawOpacity(stroke.Opacity) < 1 || stroke.BlendMode != ""
+		if transparent {
+			p.setAlpha(drawOpacity(stroke.Opacity), stroke.BlendMode)
+		}
+		p.pdf.SetDrawColor(hexToRGB(stroke.colour(p)))
+		p.pdf.SetLineWidth(stroke.width())
+		if stroke.Cap != "" {
+			p.pdf.SetLineCapStyle(lineCapStyle(stroke.Cap))
+		}
+		if stroke.Join != "" {
+			p.pdf.SetLineJoinStyle(lineJoinStyle(stroke.Join))
+		}
+		if len(stroke.Dash) > 0 {
+			p.pdf.SetDashPattern(stroke.Dash, 0)
+		}
+
+		path()
+		p.pdf.DrawPath("D")
+
+		// put the line style back to the gofpdf defaults
+		if stroke.Cap != "" {
+			p.pdf.SetLineCapStyle("butt")
+		}
+		if stroke.Join != "" {
+			p.pdf.SetLineJoinStyle("miter")
+		}
+		if len(stroke.Dash) > 0 {
+			p.pdf.SetDashPattern([]float64{}, 0)
+		}
+		if transparent {
+			p.pdf.SetAlpha(currentAlpha, currentBlend)
+		}
+	}
+
+	p.pdf.SetFillColor(currentFillR, currentFillG, currentFillB)
+	p.pdf.SetDrawColor(currentDrawR, currentDrawG, currentDrawB)
+	p.pdf.SetLineWidth(currentWeight)
+	p.pdf.SetXY(currentX, currentY)
+}
+
+// returns the gofpdf line cap style for a Stroke's Cap
+func lineCapStyle(style string) string {
+	switch strings.ToLower(style) {
+	case "butt", "round", "square":
+		return strings.ToLower(style)
+	}
+	log.Fatalf("Invalid line cap (%s)\n", style)
+	return ""
+}
+
+// returns the gofpdf line join style for a Stroke's Join
+func lineJoinStyle(style string) string {
+	switch strings.ToLower(style) {
+	case "mitre", "miter":
+		return "miter"
+	case "round", "bevel":
+		return strings.ToLower(style)
+	}
+	log.Fatalf("Invalid line join (%s)\n", style)
+	return ""
+}
+
+// adds a rectangle to the current path
+func (p *Pdfb) rectPath(x, y, w, h float64) {
+	p.pdf.MoveTo(x, y)
+	p.pdf.LineTo(x+w, y)
+	p.pdf.LineTo(x+w, y+h)
+	p.pdf.LineTo(x, y+h)
+	p.pdf.ClosePath()
+}
+
+// adds a rectangle with rounded corners to the current path
+func (p *Pdfb) roundedRectPath(x, y, w, h float64, corners Corners) {
+	// radii can't be more than half of the shortest side
+	limit := math.Min(w, h) / 2
+	tl, tr := math.Min(corners.TopLeft, limit), math.Min(corners.TopRight, limit)
+	br, bl := math.Min(corners.BottomRight, limit), math.Min(corners.BottomLeft, limit)
+
+	p.pdf.MoveTo(x+tl, y)
+	p.pdf.LineTo(x+w-tr, y)
+	p.arcTo(x+w-tr, y+tr, tr, tr, 90, 0)
+	p.pdf.LineTo(x+w, y+h-br)
+	p.arcTo(x+w-br, y+h-br, br, br, 0, -90)
+	p.pdf.LineTo(x+bl, y+h)
+	p.arcTo(x+bl, y+h-bl, bl, bl, -90, -180)
+	p.pdf.LineTo(x, y+tl)
+	p.arcTo(x+tl, y+tl, tl, tl, 180, 90)
+	p.pdf.ClosePath()
+}
+
+// adds a closed polygon to the current path
+func (p *Pdfb) polygonPath(points []Point) {
+	p.pdf.MoveTo(points[0].X, points[0].Y)
+	for _, pt := range points[1:] {
+		p.pdf.LineTo(pt.X, pt.Y)
+	}
+	p.pdf.ClosePath()
+}
+
+// adds a closed ellipse to the current path
+func (p *Pdfb) ellipsePath(x, y, rx, ry float64) {
+	p.pdf.MoveTo(x+rx, y)
+	p.arcTo(x, y, rx, ry, 0, 360)
+	p.pdf.ClosePath()
+}
+
+// adds an arc to the current path, split into pieces small enough
+// for gofpdf to draw accurately
+func (p *Pdfb) arcTo(cx, cy, rx, ry, from, to float64) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	pieces := int(math.Ceil(math.Abs(to-from) / 45))
+	for i := 0; i < pieces; i++ {
+		a := from + (to-from)*float64(i)/float64(pieces)
+		b := from + (to-from)*float64(i+1)/float64(pieces)
+		p.pdf.ArcTo(cx, cy, rx, ry, 0, a, b)
+	}
+}
@@ -0,0 +1,1122 @@
+package pdfb
+
+import (
+	"encoding/xml"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// svgNode is an element of a parsed SVG document
+type svgNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []svgNode  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// returns the value of an attribute, or "" if it isn't set
+func (n *svgNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// svgMatrix is an affine transformation [a b c d e f], mapping
+// (x, y) to (a*x + c*y + e, b*x + d*y + f)
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+// returns the transformation of applying n and then m
+func (m svgMatrix) multiply(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// transforms a point
+func (m svgMatrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// returns how much lengths are scaled by on average
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// svgPaint is a fill or stroke colour, or a reference to a gradient.
+// transparency is 1 minus the alpha of colours such as #rrggbbaa or rgba(),
+// so that colours are opaque by default
+type svgPaint struct {
+	none         bool
+	r, g, b      int
+	transparency float64
+	gradient     string
+}
+
+// returns the opacity of the colour
+func (c svgPaint) alpha() float64 {
+	return 1 - c.transparency
+}
+
+// svgStyle holds the presentation attributes that are inherited by children
+type svgStyle struct {
+	fill          svgPaint
+	stroke        svgPaint
+	strokeWidth   float64
+	lineCap       string
+	lineJoin      string
+	dashArray     []float64
+	opacity       float64
+	fillOpacity   float64
+	strokeOpacity float64
+	evenOdd       bool
+	fontSize      float64
+	bold          bool
+	textAnchor    string
+}
+
+// svgGradient is a linear gradient defined in an SVG document
+type svgGradient struct {
+	x1, y1, x2, y2 float64
+	userSpace      bool
+	transform      svgMatrix
+	stops          []gradientStop
+}
+
+// svgDrawer holds the state used while drawing an SVG document
+type svgDrawer struct {
+	p         *Pdfb
+	gradients map[string]*svgGradient
+}
+
+// SVG is used to draw an SVG image as vector graphics
+// Use 0 in place of w or h to keep the aspect ratio
+//
+// Paths, rect, circle, ellipse, line, polyline, polygon, text and groups are
+// drawn, with transforms, fill, stroke, opacity and linear gradients
+func (p *Pdfb) SVG(r io.Reader, x, y, w, h float64) {
+	var root svgNode
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		log.Fatalf("SVG could not be read (%s)\n", err)
+	}
+	if root.XMLName.Local != "svg" {
+		log.Fatalf("SVG could not be read (root element is %s)\n", root.XMLName.Local)
+	}
+
+	// the size of the image in mm and the area of the drawing it shows
+	naturalW, naturalH := svgLengthMM(root.attr("width")), svgLengthMM(root.attr("height"))
+	var viewBox []float64
+	if vb := root.attr("viewBox"); vb != "" {
+		viewBox = svgNumbers(vb)
+	}
+	if len(viewBox) != 4 {
+		viewBox = []float64{0, 0, naturalW * 96 / 25.4, naturalH * 96 / 25.4}
+	}
+	if viewBox[2] <= 0 || viewBox[3] <= 0 {
+		log.Fatalln("SVG could not be read (it has no size)")
+	}
+	if naturalW == 0 || naturalH == 0 {
+		naturalW, naturalH = viewBox[2]*25.4/96, viewBox[3]*25.4/96
+	}
+
+	// calc w and/or h values if 0 is given
+	switch {
+	case w == 0 && h == 0:
+		w, h = naturalW, naturalH
+	case w == 0:
+		w = h * viewBox[2] / viewBox[3]
+	case h == 0:
+		h = w * viewBox[3] / viewBox[2]
+	}
+
+	// fit the view box inside w and h, centred, unless told to stretch it
+	sx, sy := w/viewBox[2], h/viewBox[3]
+	offsetX, offsetY := 0.0, 0.0
+	if !strings.HasPrefix(root.attr("preserveAspectRatio"), "none") {
+		s := math.Min(sx, sy)
+		offsetX, offsetY = (w-viewBox[2]*s)/2, (h-viewBox[3]*s)/2
+		sx, sy = s, s
+	}
+	m := svgMatrix{sx, 0, 0, sy, x + offsetX - viewBox[0]*sx, y + offsetY - viewBox[1]*sy}
+
+	d := &svgDrawer{p: p, gradients: map[string]*svgGradient{}}
+	d.collectGradients(&root)
+	d.linkGradients(&root)
+
+	// save the state so everything set while drawing can be put back
+	currentFillR, currentFillG, currentFillB := p.pdf.GetFillColor()
+	currentDrawR, currentDrawG, currentDrawB := p.pdf.GetDrawColor()
+	currentTextR, currentTextG, currentTextB := p.pdf.GetTextColor()
+	currentWeight := p.pdf.GetLineWidth()
+	currentAlpha, currentBlend := p.pdf.GetAlpha()
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	currentX, currentY := p.GetX(), p.GetY()
+
+	style := svgStyle{
+		fill:          svgPaint{},
+		stroke:        svgPaint{none: true},
+		strokeWidth:   1,
+		opacity:       1,
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		fontSize:      16,
+	}
+	d.drawNode(&root, m, style)
+
+	p.pdf.SetFillColor(currentFillR, currentFillG, currentFillB)
+	p.pdf.SetDrawColor(currentDrawR, currentDrawG, currentDrawB)
+	p.pdf.SetTextColor(currentTextR, currentTextG, currentTextB)
+	p.pdf.SetLineWidth(currentWeight)
+	p.pdf.SetLineCapStyle("butt")
+	p.pdf.SetLineJoinStyle("miter")
+	p.pdf.SetDashPattern([]float64{}, 0)
+	p.pdf.SetAlpha(currentAlpha, currentBlend)
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+	p.pdf.SetXY(currentX, currentY)
+
+	p.checkpoint("SVG printed")
+}
+
+// finds the linear gradients defined anywhere in the document
+func (d *svgDrawer) collectGradients(n *svgNode) {
+	if n.XMLName.Local == "linearGradient" && n.attr("id") != "" {
+		g := &svgGradient{
+			x1:        svgLength(n.attr("x1"), 0, 1),
+			y1:        svgLength(n.attr("y1"), 0, 1),
+			x2:        svgLength(n.attr("x2"), 1, 1),
+			y2:        svgLength(n.attr("y2"), 0, 1),
+			userSpace: n.attr("gradientUnits") == "userSpaceOnUse",
+			transform: svgTransform(n.attr("gradientTransform")),
+		}
+		for i := range n.Children {
+			stop := &n.Children[i]
+			if stop.XMLName.Local != "stop" {
+				continue
+			}
+			props := svgProperties(stop)
+			paint := svgColour(props["stop-color"])
+			g.stops = append(g.stops, gradientStop{
+				offset: svgLength(stop.attr("offset"), 0, 1),
+				r:      paint.r,
+				g:      paint.g,
+				b:      paint.b,
+			})
+		}
+		d.gradients[n.attr("id")] = g
+	}
+
+	for i := range n.Children {
+		d.collectGradients(&n.Children[i])
+	}
+}
+
+// copies stops into gradients that refer to another gradient with href
+func (d *svgDrawer) linkGradients(n *svgNode) {
+	if n.XMLName.Local == "linearGradient" {
+		href := strings.TrimPrefix(n.attr("href"), "#")
+		g, ok := d.gradients[n.attr("id")]
+		if ref, found := d.gradients[href]; ok && found && len(g.stops) == 0 {
+			g.stops = ref.stops
+		}
+	}
+	for i := range n.Children {
+		d.linkGradients(&n.Children[i])
+	}
+}
+
+// draws an element and its children
+func (d *svgDrawer) drawNode(n *svgNode, m svgMatrix, style svgStyle) {
+	switch n.XMLName.Local {
+	case "defs", "linearGradient", "radialGradient", "clipPath", "mask", "symbol", "title", "desc", "metadata", "style":
+		return
+	}
+
+	if t := n.attr("transform"); t != "" {
+		m = m.multiply(svgTransform(t))
+	}
+	style = d.inheritStyle(n, style)
+	if svgProperties(n)["display"] == "none" {
+		return
+	}
+
+	var path []pathSegment
+	switch n.XMLName.Local {
+	case "svg", "g", "a":
+		for i := range n.Children {
+			d.drawNode(&n.Children[i], m, style)
+		}
+		return
+	case "text":
+		d.drawText(n, m, style)
+		return
+	case "path":
+		path = svgParsePath(n.attr("d"))
+	case "rect":
+		path = svgRectPath(n)
+	case "circle":
+		r := svgLength(n.attr("r"), 0, 0)
+		path = svgEllipsePath(svgLength(n.attr("cx"), 0, 0), svgLength(n.attr("cy"), 0, 0), r, r)
+	case "ellipse":
+		path = svgEllipsePath(svgLength(n.attr("cx"), 0, 0), svgLength(n.attr("cy"), 0, 0), svgLength(n.attr("rx"), 0, 0), svgLength(n.attr("ry"), 0, 0))
+	case "line":
+		path = []pathSegment{
+			{'M', []float64{svgLength(n.attr("x1"), 0, 0), svgLength(n.attr("y1"), 0, 0)}},
+			{'L', []float64{svgLength(n.attr("x2"), 0, 0), svgLength(n.attr("y2"), 0, 0)}},
+		}
+		// lines have nothing to fill
+		style.fill.none = true
+	case "polyline", "polygon":
+		pts := svgNumbers(n.attr("points"))
+		for i := 0; i+1 < len(pts); i += 2 {
+			op := byte('L')
+			if i == 0 {
+				op = 'M'
+			}
+			path = append(path, pathSegment{op, []float64{pts[i], pts[i+1]}})
+		}
+		if n.XMLName.Local == "polygon" {
+			path = append(path, pathSegment{op: 'Z'})
+		}
+	default:
+		return
+	}
+
+	if len(path) > 0 {
+		d.drawPath(path, m, style)
+	}
+}
+
+// reads the style of an element, falling back to the inherited style
+func (d *svgDrawer) inheritStyle(n *svgNode, style svgStyle) svgStyle {
+	props := svgProperties(n)
+
+	// opacity applies to the element as a whole, so it multiplies
+	// with the opacity of its parents
+	style.opacity *= svgLength(props["opacity"], 1, 1)
+
+	for name, value := range props {
+		// inherited values are already in the style
+		if strings.EqualFold(value, "inherit") {
+			continue
+		}
+		switch name {
+		case "fill":
+			style.fill = svgColour(value)
+		case "stroke":
+			style.stroke = svgColour(value)
+		case "stroke-width":
+			style.strokeWidth = svgLength(value, style.strokeWidth, 0)
+		case "stroke-linecap":
+			style.lineCap = value
+		case "stroke-linejoin":
+			style.lineJoin = value
+		case "stroke-dasharray":
+			style.dashArray = svgNumbers(value)
+		case "fill-opacity":
+			style.fillOpacity = svgLength(value, 1, 1)
+		case "stroke-opacity":
+			style.strokeOpacity = svgLength(value, 1, 1)
+		case "fill-rule":
+			style.evenOdd = value == "evenodd"
+		case "font-size":
+			style.fontSize = svgLength(value, style.fontSize, style.fontSize)
+		case "font-weight":
+			weight, err := strconv.Atoi(value)
+			style.bold = value == "bold" || value == "bolder" || (err == nil && weight >= 600)
+		case "text-anchor":
+			style.textAnchor = value
+		}
+	}
+
+	return style
+}
+
+// draws the fill and then the stroke of a path
+func (d *svgDrawer) drawPath(path []pathSegment, m svgMatrix, style svgStyle) {
+	p := d.p
+
+	if !style.fill.none {
+		if g, ok := d.gradients[style.fill.gradient]; ok && len(g.stops) > 0 {
+			d.fillGradient(path, m, style, g)
+		} else if style.fill.gradient == "" {
+			p.setAlpha(style.opacity*style.fillOpacity*style.fill.alpha(), "")
+			p.pdf.SetFillColor(style.fill.r, style.fill.g, style.fill.b)
+			d.outputPath(path, m)
+			if style.evenOdd {
+				p.pdf.DrawPath("F*")
+			} else {
+				p.pdf.DrawPath("F")
+			}
+		}
+	}
+
+	if !style.stroke.none && style.strokeWidth > 0 {
+		p.setAlpha(style.opacity*style.strokeOpacity*style.stroke.alpha(), "")
+		p.pdf.SetDrawColor(style.stroke.r, style.stroke.g, style.stroke.b)
+		p.pdf.SetLineWidth(style.strokeWidth * m.scale())
+
+		switch style.lineCap {
+		case "round", "square":
+			p.pdf.SetLineCapStyle(style.lineCap)
+		default:
+			p.pdf.SetLineCapStyle("butt")
+		}
+		switch style.lineJoin {
+		case "round", "bevel":
+			p.pdf.SetLineJoinStyle(style.lineJoin)
+		default:
+			p.pdf.SetLineJoinStyle("miter")
+		}
+		dashes := make([]float64, len(style.dashArray))
+		for i, dash := range style.dashArray {
+			dashes[i] = dash * m.scale()
+		}
+		p.pdf.SetDashPattern(dashes, 0)
+
+		d.outputPath(path, m)
+		p.pdf.DrawPath("D")
+	}
+}
+
+// writes the path to the page, transformed by m
+func (d *svgDrawer) outputPath(path []pathSegment, m svgMatrix) {
+	for _, s := range path {
+		switch s.op {
+		case 'M':
+			d.p.pdf.MoveTo(m.apply(s.pts[0], s.pts[1]))
+		case 'L':
+			d.p.pdf.LineTo(m.apply(s.pts[0], s.pts[1]))
+		case 'C':
+			x1, y1 := m.apply(s.pts[0], s.pts[1])
+			x2, y2 := m.apply(s.pts[2], s.pts[3])
+			x, y := m.apply(s.pts[4], s.pts[5])
+			d.p.pdf.CurveBezierCubicTo(x1, y1, x2, y2, x, y)
+		case 'Z':
+			d.p.pdf.ClosePath()
+		}
+	}
+}
+
+// fills a path with a linear gradient, by clipping to the path
+// and painting the gradient over its bounding box
+func (d *svgDrawer) fillGradient(path []pathSegment, m svgMatrix, style svgStyle, g *svgGradient) {
+	p := d.p
+
+	// bounding box of the path before and after transforming
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	pageMinX, pageMinY, pageMaxX, pageMaxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, s := range path {
+		for i := 0; i+1 < len(s.pts); i += 2 {
+			minX, maxX = math.Min(minX, s.pts[i]), math.Max(maxX, s.pts[i])
+			minY, maxY = math.Min(minY, s.pts[i+1]), math.Max(maxY, s.pts[i+1])
+			x, y := m.apply(s.pts[i], s.pts[i+1])
+			pageMinX, pageMaxX = math.Min(pageMinX, x), math.Max(pageMaxX, x)
+			pageMinY, pageMaxY = math.Min(pageMinY, y), math.Max(pageMaxY, y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return
+	}
+
+	// gradient vector in the coordinates of the page
+	gm := m
+	if !g.userSpace {
+		gm = gm.multiply(svgMatrix{maxX - minX, 0, 0, maxY - minY, minX, minY})
+	}
+	gm = gm.multiply(g.transform)
+	x1, y1 := gm.apply(g.x1, g.y1)
+	x2, y2 := gm.apply(g.x2, g.y2)
+
+	p.setAlpha(style.opacity*style.fillOpacity, "")
+
+	// clip to the path
+	p.pdf.RawWriteStr("q")
+	d.outputPath(path, m)
+	if style.evenOdd {
+		p.pdf.RawWriteStr("W* n")
+	} else {
+		p.pdf.RawWriteStr("W n")
+	}
+
+	p.paintLinearGradient(g.stops, x1, y1, x2, y2, pageMinX, pageMinY, pageMaxX-pageMinX, pageMaxY-pageMinY)
+
+	p.pdf.RawWriteStr("Q")
+}
+
+// draws a text element using the document font
+func (d *svgDrawer) drawText(n *svgNode, m svgMatrix, style svgStyle) {
+	p := d.p
+
+	text := n.Text
+	for i := range n.Children {
+		if n.Children[i].XMLName.Local == "tspan" {
+			text += n.Children[i].Text
+		}
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" || style.fill.none {
+		return
+	}
+
+	// font sizes are in points, sizes on the page are in mm
+	size := style.fontSize * m.scale()
+	p.SetFont(Font{Family: p.font.Family, Bold: style.bold, Size: size * p.pdf.GetConversionRatio()})
+
+	x, y := m.apply(svgLength(n.attr("x"), 0, 0), svgLength(n.attr("y"), 0, 0))
+	switch style.textAnchor {
+	case "middle":
+		x -= p.stringWidth(text) / 2
+	case "end":
+		x -= p.stringWidth(text)
+	}
+
+	p.setAlpha(style.opacity*style.fillOpacity*style.fill.alpha(), "")
+	p.pdf.SetTextColor(style.fill.r, style.fill.g, style.fill.b)
+
+	// rotate the text to follow the transform
+	angle := math.Atan2(m[1], m[0]) * 180 / math.Pi
+	p.pdf.TransformBegin()
+	p.pdf.TransformRotate(-angle, x, y)
+	p.textAt(x, y, text)
+	p.pdf.TransformEnd()
+}
+
+// returns the presentation attributes of an element, including
+// those set in its style attribute
+func svgProperties(n *svgNode) map[string]string {
+	props := map[string]string{}
+	for _, a := range n.Attrs {
+		props[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	for _, decl := range strings.Split(n.attr("style"), ";") {
+		if parts := strings.SplitN(decl, ":", 2); len(parts) == 2 {
+			props[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return props
+}
+
+// named colours that are likely to be used in hand written SVGs
+var svgNamedColours = map[string]string{
+	"black":   "#000000",
+	"white":   "#ffffff",
+	"red":     "#ff0000",
+	"green":   "#008000",
+	"lime":    "#00ff00",
+	"blue":    "#0000ff",
+	"yellow":  "#ffff00",
+	"cyan":    "#00ffff",
+	"magenta": "#ff00ff",
+	"gray":    "#808080",
+	"grey":    "#808080",
+	"silver":  "#c0c0c0",
+	"maroon":  "#800000",
+	"olive":   "#808000",
+	"navy":    "#000080",
+	"purple":  "#800080",
+	"teal":    "#008080",
+	"orange":  "#ffa500",
+	"pink":    "#ffc0cb",
+	"brown":   "#a52a2a",
+}
+
+// parses a colour, such as none, #f00, rgb(255, 0, 0), rgba(255 0 0 / 50%),
+// hsl(0, 100%, 50%), red or url(#gradient)
+func svgColour(value string) svgPaint {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	switch {
+	case value == "" || value == "currentcolor" || value == "inherit":
+		return svgPaint{}
+	case value == "none" || value == "transparent":
+		return svgPaint{none: true}
+	case strings.HasPrefix(value, "url("):
+		id := strings.TrimSuffix(strings.TrimPrefix(value, "url("), ")")
+		return svgPaint{gradient: strings.Trim(id, "#'\" ")}
+	case strings.HasPrefix(value, "rgb(") || strings.HasPrefix(value, "rgba("):
+		return svgFunctionColour(value, false)
+	case strings.HasPrefix(value, "hsl(") || strings.HasPrefix(value, "hsla("):
+		return svgFunctionColour(value, true)
+	}
+
+	if hex, ok := svgNamedColours[value]; ok {
+		value = hex
+	}
+	if !strings.HasPrefix(value, "#") {
+		// unknown colours are drawn in black
+		return svgPaint{}
+	}
+	return svgHexColour(strings.TrimPrefix(value, "#"))
+}
+
+// parses an rgb(), rgba(), hsl() or hsla() colour, with the values separated
+// by commas or spaces and the alpha after a comma or a slash, eg.
+// rgba(255, 0, 0, 0.5) or rgb(255 0 0 / 50%). Invalid colours are drawn in black
+func svgFunctionColour(value string, hsl bool) svgPaint {
+	args := value[strings.IndexByte(value, '(')+1:]
+	args = strings.TrimSuffix(strings.TrimSpace(args), ")")
+	parts := strings.FieldsFunc(args, func(c rune) bool {
+		return c == ',' || c == '/' || unicode.IsSpace(c)
+	})
+	if len(parts) != 3 && len(parts) != 4 {
+		return svgPaint{}
+	}
+
+	// reads a value, where percentages are of max
+	number := func(part string, max float64) (float64, bool) {
+		scale := 1.0
+		if strings.HasSuffix(part, "%") {
+			part, scale = strings.TrimSuffix(part, "%"), max/100
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(v) {
+			return 0, false
+		}
+		return math.Max(0, math.Min(max, v*scale)), true
+	}
+
+	var paint svgPaint
+	if hsl {
+		hue, err := strconv.ParseFloat(strings.TrimSuffix(parts[0], "deg"), 64)
+		s, sOK := number(parts[1], 1)
+		l, lOK := number(parts[2], 1)
+		if err != nil || math.IsNaN(hue) || math.IsInf(hue, 0) || !sOK || !lOK {
+			return svgPaint{}
+		}
+		hue = math.Mod(math.Mod(hue, 360)+360, 360)
+		paint.r, paint.g, paint.b = hslToRGB(hue, s, l)
+	} else {
+		var rgb [3]float64
+		for i := range rgb {
+			v, ok := number(parts[i], 255)
+			if !ok {
+				return svgPaint{}
+			}
+			rgb[i] = v
+		}
+		paint.r, paint.g, paint.b = int(math.Round(rgb[0])), int(math.Round(rgb[1])), int(math.Round(rgb[2]))
+	}
+
+	if len(parts) == 4 {
+		alpha, ok := number(parts[3], 1)
+		if !ok {
+			return svgPaint{}
+		}
+		paint.transparency = 1 - alpha
+	}
+	return paint
+}
+
+// parses a #rgb, #rgba, #rrggbb or #rrggbbaa colour, without the #.
+// Invalid colours are drawn in black, as unknown colour names are
+func svgHexColour(hex string) svgPaint {
+	// expand the shorthand forms
+	if len(hex) == 3 || len(hex) == 4 {
+		long := make([]byte, 0, len(hex)*2)
+		for i := 0; i < len(hex); i++ {
+			long = append(long, hex[i], hex[i])
+		}
+		hex = string(long)
+	}
+	if len(hex) != 6 && len(hex) != 8 {
+		return svgPaint{}
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return svgPaint{}
+	}
+	alpha := uint64(0xff)
+	if len(hex) == 8 {
+		alpha = v & 0xff
+		v >>= 8
+	}
+	return svgPaint{
+		r:            int(v >> 16 & 0xff),
+		g:            int(v >> 8 & 0xff),
+		b:            int(v & 0xff),
+		transparency: 1 - float64(alpha)/255,
+	}
+}
+
+// parses a length in user units, percentages are relative to ref
+// and def is returned when the value is empty
+func svgLength(value string, def, ref float64) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return def
+	}
+	if strings.HasSuffix(value, "%") {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return def
+		}
+		return v / 100 * ref
+	}
+	v, err := strconv.ParseFloat(strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyz"), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// parses the width or height of an SVG document into mm, 0 if it isn't set
+func svgLengthMM(value string) float64 {
+	value = strings.TrimSpace(value)
+	v := svgLength(value, 0, 0)
+	switch {
+	case strings.HasSuffix(value, "%"):
+		return 0
+	case strings.HasSuffix(value, "mm"):
+		return v
+	case strings.HasSuffix(value, "cm"):
+		return v * 10
+	case strings.HasSuffix(value, "in"):
+		return v * 25.4
+	case strings.HasSuffix(value, "pt"):
+		return v * 25.4 / 72
+	}
+	// px, or no unit
+	return v * 25.4 / 96
+}
+
+// parses a list of numbers separated by spaces and/or commas
+func svgNumbers(value string) []float64 {
+	s := &svgScanner{s: value}
+	var numbers []float64
+	for {
+		v, ok := s.number()
+		if !ok {
+			return numbers
+		}
+		numbers = append(numbers, v)
+	}
+}
+
+// parses a transform attribute, such as "translate(10 20) rotate(45)"
+func svgTransform(value string) svgMatrix {
+	m := svgIdentity
+	for _, part := range strings.Split(value, ")") {
+		parts := strings.SplitN(part, "(", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.Trim(strings.TrimSpace(parts[0]), ",")
+		args := svgNumbers(parts[1])
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+
+		var t svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			t = svgMatrix{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				multiply(svgMatrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}).
+				multiply(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.multiply(t)
+	}
+	return m
+}
+
+// returns the path of a rect element, with rounded corners if rx or ry are set
+func svgRectPath(n *svgNode) []pathSegment {
+	x, y := svgLength(n.attr("x"), 0, 0), svgLength(n.attr("y"), 0, 0)
+	w, h := svgLength(n.attr("width"), 0, 0), svgLength(n.attr("height"), 0, 0)
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	rx, ry := svgLength(n.attr("rx"), -1, w), svgLength(n.attr("ry"), -1, h)
+	switch {
+	case rx < 0 && ry < 0:
+		rx, ry = 0, 0
+	case rx < 0:
+		rx = ry
+	case ry < 0:
+		ry = rx
+	}
+	rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+
+	if rx == 0 || ry == 0 {
+		return []pathSegment{
+			{'M', []float64{x, y}},
+			{'L', []float64{x + w, y}},
+			{'L', []float64{x + w, y + h}},
+			{'L', []float64{x, y + h}},
+			{op: 'Z'},
+		}
+	}
+
+	// control point distance for a quarter circle
+	kx, ky := rx*0.5523, ry*0.5523
+	return []pathSegment{
+		{'M', []float64{x + rx, y}},
+		{'L', []float64{x + w - rx, y}},
+		{'C', []float64{x + w - rx + kx, y, x + w, y + ry - ky, x + w, y + ry}},
+		{'L', []float64{x + w, y + h - ry}},
+		{'C', []float64{x + w, y + h - ry + ky, x + w - rx + kx, y + h, x + w - rx, y + h}},
+		{'L', []float64{x + rx, y + h}},
+		{'C', []float64{x + rx - kx, y + h, x, y + h - ry + ky, x, y + h - ry}},
+		{'L', []float64{x, y + ry}},
+		{'C', []float64{x, y + ry - ky, x + rx - kx, y, x + rx, y}},
+		{op: 'Z'},
+	}
+}
+
+// returns the path of an ellipse as four bezier curves
+func svgEllipsePath(cx, cy, rx, ry float64) []pathSegment {
+	if rx <= 0 || ry <= 0 {
+		return nil
+	}
+	kx, ky := rx*0.5523, ry*0.5523
+	return []pathSegment{
+		{'M', []float64{cx + rx, cy}},
+		{'C', []float64{cx + rx, cy + ky, cx + kx, cy + ry, cx, cy + ry}},
+		{'C', []float64{cx - kx, cy + ry, cx - rx, cy + ky, cx - rx, cy}},
+		{'C', []float64{cx - rx, cy - ky, cx - kx, cy - ry, cx, cy - ry}},
+		{'C', []float64{cx + kx, cy - ry, cx + rx, cy - ky, cx + rx, cy}},
+		{op: 'Z'},
+	}
+}
+
+// svgScanner reads numbers and commands from path data
+type svgScanner struct {
+	s   string
+	pos int
+}
+
+// skips spaces and commas
+func (s *svgScanner) skip() {
+	for s.pos < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+// reads the next number, numbers can run into each other eg. "1.5.5-2"
+func (s *svgScanner) number() (float64, bool) {
+	s.skip()
+	start := s.pos
+	if s.pos < len(s.s) && (s.s[s.pos] == '-' || s.s[s.pos] == '+') {
+		s.pos++
+	}
+	dot, exp := false, false
+	for s.pos < len(s.s) {
+		c := s.s[s.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !dot && !exp:
+			dot = true
+		case (c == 'e' || c == 'E') && !exp && s.pos > start:
+			exp = true
+			if s.pos+1 < len(s.s) && (s.s[s.pos+1] == '-' || s.s[s.pos+1] == '+') {
+				s.pos++
+			}
+		default:
+			v, err := strconv.ParseFloat(s.s[start:s.pos], 64)
+			return v, err == nil
+		}
+		s.pos++
+	}
+	v, err := strconv.ParseFloat(s.s[start:s.pos], 64)
+	return v, err == nil
+}
+
+// reads an arc flag, which is a single 0 or 1 and may not be followed by a separator
+func (s *svgScanner) flag() (float64, bool) {
+	s.skip()
+	if s.pos < len(s.s) && (s.s[s.pos] == '0' || s.s[s.pos] == '1') {
+		s.pos++
+		return float64(s.s[s.pos-1] - '0'), true
+	}
+	return 0, false
+}
+
+// parses path data into absolute M, L, C and Z commands
+func svgParsePath(data string) []pathSegment {
+	s := &svgScanner{s: data}
+	var path []pathSegment
+	var cmd byte
+	var x, y, startX, startY float64
+	// the last control point, used by the smooth curve commands
+	var ctrlX, ctrlY float64
+	var lastCmd byte
+
+	for {
+		s.skip()
+		if s.pos >= len(s.s) {
+			return path
+		}
+		if c := s.s[s.pos]; strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+			s.pos++
+		} else if cmd == 0 {
+			return path
+		}
+
+		relative := cmd >= 'a'
+		abs := func(v, origin float64) float64 {
+			if relative {
+				return v + origin
+			}
+			return v
+		}
+
+		// reads n numbers for the current command
+		read := func(n int) ([]float64, bool) {
+			args := make([]float64, n)
+			for i := range args {
+				v, ok := s.number()
+				if !ok {
+					return nil, false
+				}
+				args[i] = v
+			}
+			return args, true
+		}
+
+		upper := cmd &^ 0x20
+		switch upper {
+		case 'Z':
+			path = append(path, pathSegment{op: 'Z'})
+			x, y = startX, startY
+			lastCmd = 'Z'
+			// close path takes no numbers, so a number after it ends the path
+			cmd = 0
+			continue
+		case 'M', 'L', 'T':
+			a, ok := read(2)
+			if !ok {
+				return path
+			}
+			nx, ny := abs(a[0], x), abs(a[1], y)
+			switch upper {
+			case 'M':
+				path = append(path, pathSegment{'M', []float64{nx, ny}})
+				startX, startY = nx, ny
+				// further pairs after a move are lines
+				if relative {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			case 'L':
+				path = append(path, pathSegment{'L', []float64{nx, ny}})
+			case 'T':
+				qx, qy := x, y
+				if lastCmd == 'Q' || lastCmd == 'T' {
+					qx, qy = 2*x-ctrlX, 2*y-ctrlY
+				}
+				path = append(path, quadToCubic(x, y, qx, qy, nx, ny))
+				ctrlX, ctrlY = qx, qy
+			}
+			x, y = nx, ny
+		case 'H':
+			a, ok := read(1)
+			if !ok {
+				return path
+			}
+			x = abs(a[0], x)
+			path = append(path, pathSegment{'L', []float64{x, y}})
+		case 'V':
+			a, ok := read(1)
+			if !ok {
+				return path
+			}
+			y = abs(a[0], y)
+			path = append(path, pathSegment{'L', []float64{x, y}})
+		case 'C':
+			a, ok := read(6)
+			if !ok {
+				return path
+			}
+			x1, y1 := abs(a[0], x), abs(a[1], y)
+			x2, y2 := abs(a[2], x), abs(a[3], y)
+			nx, ny := abs(a[4], x), abs(a[5], y)
+			path = append(path, pathSegment{'C', []float64{x1, y1, x2, y2, nx, ny}})
+			ctrlX, ctrlY = x2, y2
+			x, y = nx, ny
+		case 'S':
+			a, ok := read(4)
+			if !ok {
+				return path
+			}
+			x1, y1 := x, y
+			if lastCmd == 'C' || lastCmd == 'S' {
+				x1, y1 = 2*x-ctrlX, 2*y-ctrlY
+			}
+			x2, y2 := abs(a[0], x), abs(a[1], y)
+			nx, ny := abs(a[2], x), abs(a[3], y)
+			path = append(path, pathSegment{'C', []float64{x1, y1, x2, y2, nx, ny}})
+			ctrlX, ctrlY = x2, y2
+			x, y = nx, ny
+		case 'Q':
+			a, ok := read(4)
+			if !ok {
+				return path
+			}
+			qx, qy := abs(a[0], x), abs(a[1], y)
+			nx, ny := abs(a[2], x), abs(a[3], y)
+			path = append(path, quadToCubic(x, y, qx, qy, nx, ny))
+			ctrlX, ctrlY = qx, qy
+			x, y = nx, ny
+		case 'A':
+			var a [7]float64
+			for i := range a {
+				var ok bool
+				if i == 3 || i == 4 {
+					a[i], ok = s.flag()
+				} else {
+					a[i], ok = s.number()
+				}
+				if !ok {
+					return path
+				}
+			}
+			nx, ny := abs(a[5], x), abs(a[6], y)
+			path = append(path, arcToCubics(x, y, a[0], a[1], a[2], a[3] == 1, a[4] == 1, nx, ny)...)
+			x, y = nx, ny
+		}
+		lastCmd = upper
+	}
+}
+
+// converts a quadratic bezier curve to a cubic one
+func quadToCubic(x0, y0, qx, qy, x, y float64) pathSegment {
+	return pathSegment{'C', []float64{
+		x0 + 2.0/3.0*(qx-x0), y0 + 2.0/3.0*(qy-y0),
+		x + 2.0/3.0*(qx-x), y + 2.0/3.0*(qy-y),
+		x, y,
+	}}
+}
+
+// converts an SVG elliptical arc to cubic bezier curves, following the
+// endpoint to centre conversion in the SVG specification
+func arcToCubics(x1, y1, rx, ry, angle float64, large, sweep bool, x2, y2 float64) []pathSegment {
+	if x1 == x2 && y1 == y2 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	line := []pathSegment{{'L', []float64{x2, y2}}}
+	if rx == 0 || ry == 0 || math.IsInf(rx, 0) || math.IsInf(ry, 0) || math.IsNaN(rx) || math.IsNaN(ry) {
+		return line
+	}
+
+	phi := angle * math.Pi / 180
+	cosPhi, sinPhi := math.Cos(phi), math.Sin(phi)
+
+	// step 1: compute (x1', y1')
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cosPhi*dx + sinPhi*dy
+	y1p := -sinPhi*dx + cosPhi*dy
+
+	// scale up the radii if they are too small to reach
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+
+	// step 2: compute (cx', cy')
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+
+	// step 3: compute (cx, cy)
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x2)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y2)/2
+
+	// step 4: compute the start angle and sweep
+	vecAngle := func(ux, uy, vx, vy float64) float64 {
+		a := math.Atan2(uy, ux)
+		b := math.Atan2(vy, vx)
+		return b - a
+	}
+	theta := vecAngle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := vecAngle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// split into pieces of at most a quarter turn, radii too big to square
+	// leave the arc without a sweep, so it is drawn as a straight line
+	if math.IsNaN(delta) || math.IsInf(delta, 0) || math.IsNaN(theta) {
+		return line
+	}
+	pieces := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	if pieces < 1 {
+		return line
+	}
+	step := delta / float64(pieces)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+
+	point := func(t float64) (float64, float64) {
+		x, y := rx*math.Cos(t), ry*math.Sin(t)
+		return cosPhi*x - sinPhi*y + cx, sinPhi*x + cosPhi*y + cy
+	}
+	derivative := func(t float64) (float64, float64) {
+		x, y := -rx*math.Sin(t), ry*math.Cos(t)
+		return cosPhi*x - sinPhi*y, sinPhi*x + cosPhi*y
+	}
+
+	var path []pathSegment
+	t := theta
+	for i := 0; i < pieces; i++ {
+		ax, ay := point(t)
+		adx, ady := derivative(t)
+		bx, by := point(t + step)
+		bdx, bdy := derivative(t + step)
+		path = append(path, pathSegment{'C', []float64{
+			ax + k*adx, ay + k*ady,
+			bx - k*bdx, by - k*bdy,
+			bx, by,
+		}})
+		t += step
+	}
+	// finish exactly on the end point
+	last := path[len(path)-1].pts
+	last[4], last[5] = x2, y2
+
+	return path
+}
@@ -0,0 +1,107 @@
+package pdfb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSvgParsePath(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		ops  string
+		endX float64
+		endY float64
+	}{
+		{"lines", "M0 0 L10 0 20 5", "MLL", 20, 5},
+		{"relative lines", "m1 1 l2 2 h3 v-1", "MLLL", 6, 2},
+		{"numbers running together", "M1.5.5L-2-2", "ML", -2, -2},
+		{"exponents", "M0 0L1e1 2E-1", "ML", 10, 0.2},
+		{"close path", "M0 0 L5 5 Z", "MLZ", 0, 0},
+		{"number after close path", "M0 0 L5 5 Z 3 3", "MLZ", 0, 0},
+		{"arc", "M0 0 A1 1 0 0 1 1 1", "MC", 1, 1},
+		{"arc flags packed", "M0 0 a1 1 0 00 1 1", "MC", 1, 1},
+		{"arc flags and point packed", "M0 0 a1 1 0 001 1", "MC", 1, 1},
+		{"arc flags with commas", "M0,0 a1,1,0,0,0,1,1", "MC", 1, 1},
+		{"arc with no radius", "M0 0 A0 0 0 0 1 4 4", "ML", 4, 4},
+		{"truncated line", "M0 0 L10", "M", 0, 0},
+		{"truncated arc", "M0 0 a1 1 0 0", "M", 0, 0},
+		{"invalid arc flag", "M0 0 a1 1 0 2 1 1 1", "M", 0, 0},
+		{"truncated curve", "M0 0 C1 1 2 2", "M", 0, 0},
+		{"no command", "10 10", "", 0, 0},
+		{"empty", "", "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := svgParsePath(tt.data)
+
+			ops := ""
+			for _, segment := range path {
+				ops += string(segment.op)
+			}
+			if ops != tt.ops {
+				t.Fatalf("svgParsePath(%q) commands = %q, want %q", tt.data, ops, tt.ops)
+			}
+			if len(path) == 0 || path[len(path)-1].op == 'Z' {
+				return
+			}
+
+			pts := path[len(path)-1].pts
+			x, y := pts[len(pts)-2], pts[len(pts)-1]
+			if math.Abs(x-tt.endX) > 1e-9 || math.Abs(y-tt.endY) > 1e-9 {
+				t.Errorf("svgParsePath(%q) ends at %g, %g, want %g, %g", tt.data, x, y, tt.endX, tt.endY)
+			}
+		})
+	}
+}
+
+func TestArcToCubics(t *testing.T) {
+	tests := []struct {
+		name    string
+		rx, ry  float64
+		x2, y2  float64
+		large   bool
+		sweep   bool
+		ops     string
+		maxSize float64
+	}{
+		{"quarter circle", 10, 10, 10, 10, false, true, "C", 10},
+		{"half circle", 5, 5, 10, 0, false, true, "CC", 5},
+		{"large arc", 10, 10, 10, 10, true, true, "CCC", 20},
+		{"radii too small are scaled up", 1, 1, 10, 0, false, false, "CC", 5},
+		{"no radius", 0, 5, 10, 10, false, false, "L", 10},
+		{"infinite radius", math.Inf(1), 5, 10, 10, false, false, "L", 10},
+		{"NaN radius", math.NaN(), 5, 10, 10, false, false, "L", 10},
+		{"same point", 5, 5, 0, 0, false, false, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := arcToCubics(0, 0, tt.rx, tt.ry, 0, tt.large, tt.sweep, tt.x2, tt.y2)
+
+			ops := ""
+			for _, segment := range path {
+				ops += string(segment.op)
+				// the curve stays within the circle the arc is on
+				for _, v := range segment.pts {
+					if math.IsNaN(v) || math.Abs(v) > tt.maxSize*2+1e-9 {
+						t.Fatalf("arcToCubics point out of range (%g)", v)
+					}
+				}
+			}
+			if ops != tt.ops {
+				t.Fatalf("arcToCubics commands = %q, want %q", ops, tt.ops)
+			}
+			if len(path) == 0 {
+				return
+			}
+
+			pts := path[len(path)-1].pts
+			x, y := pts[len(pts)-2], pts[len(pts)-1]
+			if math.Abs(x-tt.x2) > 1e-9 || math.Abs(y-tt.y2) > 1e-9 {
+				t.Errorf("arcToCubics ends at %g, %g, want %g, %g", x, y, tt.x2, tt.y2)
+			}
+		})
+	}
+}
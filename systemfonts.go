@@ -0,0 +1,183 @@
+package pdfb
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// the directories fonts are installed in, as well as those listed in the
+// fontconfig configuration
+var systemFontDirs = []string{
+	"/usr/share/fonts",
+	"/usr/local/share/fonts",
+	"~/.local/share/fonts",
+	"~/.fonts",
+}
+
+// matches the font directories in a fontconfig file, eg. <dir prefix="xdg">fonts</dir>
+var fontconfigDir = regexp.MustCompile(`<dir(?:\s+prefix="(\w+)")?\s*>\s*([^<]+?)\s*</dir>`)
+
+// UseSystemFont is used to import a font family installed on the system, eg.
+// p.UseSystemFont("DejaVu Sans"), which can then be used with SetFont.
+// Every TrueType face of the family is imported, with the weight, width and
+// style read from the font files, so bold and italic text use the right face.
+// Fonts are looked for in /usr/share/fonts, /usr/local/share/fonts,
+// ~/.local/share/fonts, ~/.fonts and the directories set in fontconfig.
+func (p *Pdfb) UseSystemFont(family string) {
+	// the fonts are only looked for once per document
+	if p.systemFonts == nil {
+		p.systemFonts = findSystemFonts()
+	}
+
+	imported := 0
+	for _, font := range p.systemFonts {
+		if !font.info.hasFamily(family) {
+			continue
+		}
+		data, err := os.ReadFile(font.file)
+		if err != nil {
+			continue
+		}
+
+		// faces which gofpdf can't embed are skipped
+		err = p.importFontFace(family, FontFace{
+			Weight:  font.info.weight,
+			Italic:  font.info.italic,
+			Stretch: fmt.Sprintf("%g%%", font.info.stretch),
+		}, data)
+		if err == nil {
+			imported++
+		}
+	}
+
+	if imported == 0 {
+		log.Fatalf("System font could not be located (%s)\n", family)
+	}
+
+	p.checkpoint("System font imported")
+}
+
+// systemFont is a font file installed on the system and the family,
+// weight, width and style read from it
+type systemFont struct {
+	file string
+	info ttfInfo
+}
+
+// reads the names and styles of the TrueType fonts on the system, only
+// reading the tables they are kept in rather than the whole of each file
+func findSystemFonts() []systemFont {
+	fonts := []systemFont{}
+	for _, file := range systemFontFiles() {
+		if info, err := readFontInfo(file); err == nil {
+			fonts = append(fonts, systemFont{file, info})
+		}
+	}
+	return fonts
+}
+
+// reads the family, weight, width and style of a TrueType font file
+func readFontInfo(file string) (ttfInfo, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return ttfInfo{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return ttfInfo{}, err
+	}
+	tables, err := ttfReadTables(f, stat.Size(), "name", "OS/2")
+	if err != nil {
+		return ttfInfo{}, err
+	}
+	return ttfFaceInfo(tables)
+}
+
+// reports whether a font belongs to family, by its family name or its
+// typographic family name
+func (info ttfInfo) hasFamily(family string) bool {
+	for _, f := range info.families {
+		if strings.EqualFold(f, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the TrueType font files in the system font directories. Font
+// collections (.ttc) and CFF fonts (.otf) are left out as gofpdf can't embed them
+func systemFontFiles() []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, dir := range append(systemFontDirs, fontconfigDirs()...) {
+		dir = expandHome(dir)
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// skip directories which don't exist or can't be read
+				return nil
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(file), ".ttf") && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// returns the font directories set in the fontconfig configuration
+func fontconfigDirs() []string {
+	configs := []string{"/etc/fonts/fonts.conf"}
+	if matches, err := filepath.Glob("/etc/fonts/conf.d/*.conf"); err == nil {
+		configs = append(configs, matches...)
+	}
+
+	var dirs []string
+	for _, config := range configs {
+		data, err := os.ReadFile(config)
+		if err != nil {
+			continue
+		}
+		for _, m := range fontconfigDir.FindAllStringSubmatch(string(data), -1) {
+			prefix, dir := m[1], m[2]
+			switch {
+			case prefix == "xdg":
+				dataHome := os.Getenv("XDG_DATA_HOME")
+				if dataHome == "" {
+					dataHome = "~/.local/share"
+				}
+				dir = filepath.Join(dataHome, dir)
+			case prefix == "relative" && !filepath.IsAbs(dir):
+				dir = filepath.Join(filepath.Dir(config), dir)
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// replaces a leading ~ in a path with the home directory, returning ""
+// if there isn't one
+func expandHome(dir string) string {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, dir[1:])
+}
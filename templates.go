@@ -0,0 +1,253 @@
+package pdfb
+
+import (
+	"log"
+)
+
+// PageTemplate defines a named page layout which can be selected with PageWith.
+// Any values left empty fall back to the document defaults.
+type PageTemplate struct {
+	Margin             float64
+	Background         string
+	BackgroundGradient *Gradient
+	Watermark          Watermark
+	Columns            int
+	ColumnGap          float64
+	HeaderFont         string
+	Header             []TextAlign
+	FooterFont         string
+	Footer             []TextAlign
+
+	// FirstHeader and FirstFooter are used on the first page of a section,
+	// EvenHeader and EvenFooter are used on even pages. Header and Footer
+	// are used when these are nil, an empty slice leaves the page blank.
+	FirstHeader []TextAlign
+	FirstFooter []TextAlign
+	EvenHeader  []TextAlign
+	EvenFooter  []TextAlign
+
+	// MirrorEven swaps the left and right alignment of Header and Footer
+	// on even pages, for double-sided printing
+	MirrorEven bool
+
+	// HeaderFunc and FooterFunc are used to draw the header and footer
+	// with any content, in place of the text sections
+	HeaderFunc func(ctx PageContext)
+	FooterFunc func(ctx PageContext)
+
+	// HeaderHeight and FooterHeight default to 25mm
+	HeaderHeight float64
+	FooterHeight float64
+}
+
+// pageInfo records the layout a page was added with, so that its header
+// and footer can be drawn once the document is complete
+type pageInfo struct {
+	template     PageTemplate
+	sectionStart bool
+	watermark    Watermark
+}
+
+// AddPageTemplate is used to register a named page template
+func (p *Pdfb) AddPageTemplate(name string, template PageTemplate) {
+	if name == "" {
+		log.Fatalln("Page templates must be given a name")
+	}
+
+	t := template
+	p.templates[name] = &t
+
+	p.checkpoint("Page template added")
+}
+
+// PageWith is used to insert a new page using a named page template.
+// Pages added afterwards, including those added by automatic page breaks,
+// continue to use the template until a different one is chosen.
+// Each call starts a new section, whose first page uses the template's
+// FirstHeader and FirstFooter.
+func (p *Pdfb) PageWith(name string) {
+	t, ok := p.templates[name]
+	if !ok {
+		log.Fatalf("Page template could not be located (%s)\n", name)
+	}
+
+	p.template = name
+	p.newSection = true
+	p.applyTemplate(t)
+	p.Page()
+
+	p.checkpoint("Page added with template")
+}
+
+// GetPageTemplate is used to get the name of the page template in use
+func (p *Pdfb) GetPageTemplate() string {
+	return p.template
+}
+
+// returns the template in use
+func (p *Pdfb) currentTemplate() *PageTemplate {
+	return p.templates[p.template]
+}
+
+// returns the side margin of a template
+func (p *Pdfb) templateMargin(t *PageTemplate) float64 {
+	if t.Margin > 0 {
+		return t.Margin
+	}
+	return p.margin
+}
+
+// sets the margins, header/footer heights and page break trigger
+// used by pages added with the template
+func (p *Pdfb) applyTemplate(t *PageTemplate) {
+	margin := p.templateMargin(t)
+
+	p.headerHeight = 0
+	if t.hasHeader() {
+		p.headerHeight = t.headerHeight()
+	}
+	p.footerHeight = 0
+	if t.hasFooter() {
+		p.footerHeight = t.footerHeight()
+	}
+
+	top := margin
+	if p.headerHeight > 0 {
+		top = p.headerHeight
+	}
+	bottom := margin
+	if p.footerHeight > 0 {
+		bottom = p.footerHeight
+	}
+
+	p.pdf.SetMargins(margin, top, margin)
+	p.pdf.SetAutoPageBreak(true, bottom)
+}
+
+// returns the y position that content starts from on the current page
+func (p *Pdfb) contentTop() float64 {
+	if p.headerHeight > 0 {
+		return p.headerHeight
+	}
+	return p.templateMargin(p.currentTemplate())
+}
+
+// moves the left and right margins to the given column of the current template
+func (p *Pdfb) setColumn(column int) {
+	t := p.currentTemplate()
+	margin := p.templateMargin(t)
+	pageWidth := p.GetPageWidth()
+
+	p.column = column
+	if t.Columns < 2 {
+		p.pdf.SetLeftMargin(margin)
+		p.pdf.SetRightMargin(margin)
+		return
+	}
+
+	columnWidth := (pageWidth - margin*2 - t.ColumnGap*float64(t.Columns-1)) / float64(t.Columns)
+	x := margin + float64(column)*(columnWidth+t.ColumnGap)
+
+	p.pdf.SetLeftMargin(x)
+	p.pdf.SetRightMargin(pageWidth - x - columnWidth)
+	p.pdf.SetX(x)
+}
+
+// called when an automatic page break is triggered, moves onto the next
+// column instead of the next page while there are columns left
+func (p *Pdfb) acceptPageBreak() bool {
+	// gofpdf asks even when automatic page breaks are turned off
+	if auto, _ := p.pdf.GetAutoPageBreak(); !auto {
+		return false
+	}
+
+	t := p.currentTemplate()
+	if t.Columns > 1 && p.column < t.Columns-1 {
+		p.setColumn(p.column + 1)
+		p.pdf.SetY(p.contentTop())
+		return false
+	}
+
+	// gofpdf keeps the x position over the page break, so move back to
+	// the first column before the new page is added
+	p.setColumn(0)
+	return true
+}
+
+// draws the background and watermark of a new page, and records
+// the template used so the header and footer can be drawn later
+func (p *Pdfb) drawPageStart() {
+	// the font is set on the first page if it hasn't been set yet, so the
+	// default font is only imported by documents which use it
+	if !p.fontSet {
+		p.SetFont(p.font)
+	}
+
+	t := p.currentTemplate()
+
+	// the template's watermark is used in place of the document's
+	watermark := p.watermark
+	if t.Watermark.Text != "" || t.Watermark.Image != "" {
+		watermark = t.Watermark
+	}
+
+	p.pages[p.pdf.PageNo()] = &pageInfo{
+		template:     *t,
+		sectionStart: p.newSection,
+		watermark:    watermark,
+	}
+	p.newSection = false
+
+	// the background and watermark aren't drawn with the opacity and blend
+	// mode of WithOpacity and WithBlendMode, which are set again afterwards
+	// as gofpdf doesn't carry them over to the new page
+	currentOpacity, currentBlend := p.opacity, p.blendMode
+	p.opacity, p.blendMode = 1, "Normal"
+	if currentOpacity < 1 || currentBlend != "Normal" {
+		p.pdf.SetAlpha(1, "Normal")
+	}
+
+	// used to draw the background colour
+	p.bgFunc()
+
+	// watermarks in front of the content are drawn once the document is complete
+	if !watermark.inFront() {
+		p.drawWatermark(watermark)
+	}
+
+	p.opacity, p.blendMode = currentOpacity, currentBlend
+	if p.opacity < 1 || p.blendMode != "Normal" {
+		p.pdf.SetAlpha(p.opacity, p.blendMode)
+	}
+
+	// carry on with the layer in use on the previous page
+	if p.layer >= 0 {
+		p.pdf.BeginLayer(p.layer)
+	}
+
+	// start writing at the top of the first column
+	p.setColumn(0)
+	p.pdf.SetY(p.contentTop())
+}
+
+// draws the page background, using the template background if one is set
+func (p *Pdfb) drawBackground() {
+	t := p.currentTemplate()
+	background, gradient := p.background, p.backgroundGradient
+	if t.Background != "" || t.BackgroundGradient != nil {
+		background, gradient = t.Background, t.BackgroundGradient
+	}
+
+	currentR, currentG, currentB := p.pdf.GetFillColor()
+	w, h := p.pdf.GetPageSize()
+	if gradient != nil {
+		p.pdf.ClipRect(0, 0, w, h, false)
+		p.paintGradient(gradient, 0, 0, w, h)
+		p.pdf.ClipEnd()
+	} else {
+		r, g, b := hexToRGB(background)
+		p.pdf.SetFillColor(r, g, b)
+		p.pdf.Rect(0, 0, w, h, "F")
+	}
+	p.pdf.SetFillColor(currentR, currentG, currentB)
+}
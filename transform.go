@@ -0,0 +1,78 @@
+package pdfb
+
+// Transform is used to move, rotate, scale, skew and mirror everything drawn
+// inside Pdfb.Transform. Each call adds to the transformations before it.
+type Transform struct {
+	p *Pdfb
+}
+
+// Transform is used to draw content with transformations, which are set
+// by calling the methods of t inside fn before drawing
+// Eg. rotating text to run up the spine of a cover
+//
+//	p.Transform(func(t *pdfb.Transform) {
+//		t.Rotate(90, x, y)
+//		p.SetY(y)
+//		p.SetX(x)
+//		p.Write("Annual Report")
+//	})
+//
+// Automatic page breaks are turned off inside fn, as the position of
+// transformed content on the page no longer follows the cursor.
+func (p *Pdfb) Transform(fn func(t *Transform)) {
+	auto, bottom := p.pdf.GetAutoPageBreak()
+	p.pdf.SetAutoPageBreak(false, bottom)
+	p.pdf.TransformBegin()
+
+	fn(&Transform{p: p})
+
+	p.pdf.TransformEnd()
+	p.pdf.SetAutoPageBreak(auto, bottom)
+	p.restoreGraphicsState()
+
+	p.checkpoint("Transform used")
+}
+
+// Rotate is used to rotate by angle degrees counter-clockwise around x, y
+func (t *Transform) Rotate(angle, x, y float64) {
+	t.p.pdf.TransformRotate(angle, x, y)
+}
+
+// Scale is used to scale by sx horizontally and sy vertically from x, y
+// Eg. 2 doubles the size and 0.5 halves it
+func (t *Transform) Scale(sx, sy, x, y float64) {
+	t.p.pdf.TransformScale(sx*100, sy*100, x, y)
+}
+
+// Skew is used to skew by angleX degrees horizontally and angleY degrees
+// vertically around x, y, angles must be between -90 and 90
+func (t *Transform) Skew(angleX, angleY, x, y float64) {
+	t.p.pdf.TransformSkew(angleX, angleY, x, y)
+}
+
+// Translate is used to move by tx horizontally and ty vertically
+func (t *Transform) Translate(tx, ty float64) {
+	t.p.pdf.TransformTranslate(tx, ty)
+}
+
+// MirrorHorizontal is used to flip left to right around the vertical line at x
+func (t *Transform) MirrorHorizontal(x float64) {
+	t.p.pdf.TransformMirrorHorizontal(x)
+}
+
+// MirrorVertical is used to flip top to bottom around the horizontal line at y
+func (t *Transform) MirrorVertical(y float64) {
+	t.p.pdf.TransformMirrorVertical(y)
+}
+
+// sets the colours, line width, font and opacity again once the graphics
+// state has been restored by the end of a transform or clip, as gofpdf
+// only keeps track of the last values that were set
+func (p *Pdfb) restoreGraphicsState() {
+	p.pdf.SetFillColor(p.pdf.GetFillColor())
+	p.pdf.SetDrawColor(p.pdf.GetDrawColor())
+	p.pdf.SetLineWidth(p.pdf.GetLineWidth())
+	family, styleStr := p.fontFace(p.font)
+	p.pdf.SetFont(family, styleStr, p.font.Size)
+	p.pdf.SetAlpha(p.opacity, p.blendMode)
+}
@@ -0,0 +1,318 @@
+package pdfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// runeRange is a range of characters from lo to hi inclusive
+type runeRange struct {
+	lo, hi rune
+}
+
+// runeSet is a set of characters, stored as sorted ranges
+type runeSet []runeRange
+
+// reports whether the set contains r
+func (s runeSet) has(r rune) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i].hi >= r })
+	return i < len(s) && s[i].lo <= r
+}
+
+// adds r to the end of the set, characters must be added in order
+func (s runeSet) add(r rune) runeSet {
+	if n := len(s); n > 0 && s[n-1].hi+1 >= r {
+		if r > s[n-1].hi {
+			s[n-1].hi = r
+		}
+		return s
+	}
+	return append(s, runeRange{r, r})
+}
+
+// returns the tables of a TrueType font by their tags
+func ttfTables(data []byte) (map[string][]byte, error) {
+	return ttfReadTables(bytes.NewReader(data), int64(len(data)))
+}
+
+// reads the tables of a TrueType font by their tags, only reading the tables
+// named, or every table if none are, so fonts on disk needn't be read whole.
+// Fonts with CFF outlines and font collections can't be embedded by gofpdf,
+// so they are reported as errors
+func ttfReadTables(r io.ReaderAt, size int64, tags ...string) (map[string][]byte, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, errors.New("font file is too short")
+	}
+	switch string(header[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, errors.New("font has CFF outlines")
+	case "ttcf":
+		return nil, errors.New("font is a font collection")
+	default:
+		return nil, errors.New("font is not a TrueType font")
+	}
+
+	numTables := int(binary.BigEndian.Uint16(header[4:6]))
+	directory := make([]byte, numTables*16)
+	if _, err := r.ReadAt(directory, 12); err != nil {
+		return nil, errors.New("font table directory is cut short")
+	}
+
+	tables := map[string][]byte{}
+	for i := 0; i < numTables; i++ {
+		record := directory[i*16:]
+		tag := string(record[:4])
+		if len(tags) > 0 && !slices.Contains(tags, tag) {
+			continue
+		}
+		offset := int64(binary.BigEndian.Uint32(record[8:]))
+		length := int64(binary.BigEndian.Uint32(record[12:]))
+		if offset+length > size {
+			return nil, errors.New("font table is cut short")
+		}
+		table := make([]byte, length)
+		if _, err := r.ReadAt(table, offset); err != nil && length > 0 {
+			return nil, errors.New("font table is cut short")
+		}
+		tables[tag] = table
+	}
+	return tables, nil
+}
+
+// the tables gofpdf reads from a font, which it doesn't check are there
+var ttfRequiredTables = []string{"OS/2", "cmap", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post"}
+
+// checks that gofpdf can embed a font, as it only reads some of its tables
+// once the document is output, and can't recover if they are missing then
+func ttfCheck(data []byte) error {
+	tables, err := ttfTables(data)
+	if err != nil {
+		return err
+	}
+	for _, tag := range ttfRequiredTables {
+		if _, ok := tables[tag]; !ok {
+			return fmt.Errorf("font has no %s table", tag)
+		}
+	}
+	return nil
+}
+
+// returns the characters a TrueType font has glyphs for, using its cmap table
+func ttfCoverage(data []byte) (runeSet, error) {
+	tables, err := ttfTables(data)
+	if err != nil {
+		return nil, err
+	}
+	cmap := tables["cmap"]
+	if len(cmap) < 4 {
+		return nil, errors.New("font has no cmap table")
+	}
+
+	// pick the full Unicode subtable if there is one, or the one for the
+	// basic multilingual plane
+	var best []byte
+	bestScore := 0
+	numTables := int(binary.BigEndian.Uint16(cmap[2:4]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + i*8
+		if record+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		subtable := cmap[offset:]
+		format := binary.BigEndian.Uint16(subtable)
+
+		score := 0
+		switch {
+		case format == 12 && (platform == 3 && encoding == 10 || platform == 0):
+			score = 2
+		case format == 4 && (platform == 3 && encoding == 1 || platform == 0):
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = subtable, score
+		}
+	}
+
+	switch bestScore {
+	case 2:
+		return cmapFormat12(best)
+	case 1:
+		return cmapFormat4(best)
+	}
+	return nil, errors.New("font has no Unicode cmap")
+}
+
+// reads the characters mapped by a format 4 cmap subtable
+func cmapFormat4(t []byte) (runeSet, error) {
+	if len(t) < 14 {
+		return nil, errors.New("cmap subtable is cut short")
+	}
+	segCount := int(binary.BigEndian.Uint16(t[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(t) {
+		return nil, errors.New("cmap subtable is cut short")
+	}
+
+	var set runeSet
+	for s := 0; s < segCount; s++ {
+		end := int(binary.BigEndian.Uint16(t[endCodes+s*2:]))
+		start := int(binary.BigEndian.Uint16(t[startCodes+s*2:]))
+		delta := int(binary.BigEndian.Uint16(t[idDeltas+s*2:]))
+		rangeOffset := int(binary.BigEndian.Uint16(t[idRangeOffsets+s*2:]))
+
+		for c := start; c <= end && c != 0xffff; c++ {
+			glyph := (c + delta) & 0xffff
+			if rangeOffset != 0 {
+				at := idRangeOffsets + s*2 + rangeOffset + (c-start)*2
+				if at+2 > len(t) {
+					break
+				}
+				glyph = int(binary.BigEndian.Uint16(t[at:]))
+				if glyph != 0 {
+					glyph = (glyph + delta) & 0xffff
+				}
+			}
+			if glyph != 0 {
+				set = set.add(rune(c))
+			}
+		}
+	}
+	return set, nil
+}
+
+// reads the characters mapped by a format 12 cmap subtable
+func cmapFormat12(t []byte) (runeSet, error) {
+	if len(t) < 16 {
+		return nil, errors.New("cmap subtable is cut short")
+	}
+	groups := int(binary.BigEndian.Uint32(t[12:]))
+	if 16+groups*12 > len(t) {
+		return nil, errors.New("cmap subtable is cut short")
+	}
+
+	var set runeSet
+	for g := 0; g < groups; g++ {
+		group := t[16+g*12:]
+		lo := rune(binary.BigEndian.Uint32(group))
+		hi := rune(binary.BigEndian.Uint32(group[4:]))
+		if n := len(set); n > 0 && set[n-1].hi+1 >= lo {
+			if hi > set[n-1].hi {
+				set[n-1].hi = hi
+			}
+			continue
+		}
+		set = append(set, runeRange{lo, hi})
+	}
+	return set, nil
+}
+
+// ttfInfo is the family, weight, width and style of a TrueType font, as
+// given by its name and OS/2 tables
+type ttfInfo struct {
+	families []string
+	weight   int
+	stretch  float64
+	italic   bool
+}
+
+// the widths of the OS/2 usWidthClass values 1 to 9, as percentages
+var ttfWidths = []float64{50, 62.5, 75, 87.5, 100, 112.5, 125, 150, 200}
+
+// returns the family, weight, width and style of a TrueType font from its
+// name and OS/2 tables. Both the family name and the typographic family
+// name are returned, since the family name of eg. a light face is often
+// "Inter Light"
+func ttfFaceInfo(tables map[string][]byte) (ttfInfo, error) {
+	info := ttfInfo{weight: 400, stretch: 100}
+	for _, id := range []uint16{16, 1} {
+		if family := ttfName(tables["name"], id); family != "" {
+			info.families = append(info.families, family)
+		}
+	}
+	if len(info.families) == 0 {
+		return ttfInfo{}, errors.New("font has no family name")
+	}
+
+	if os2 := tables["OS/2"]; len(os2) >= 64 {
+		if weight := int(binary.BigEndian.Uint16(os2[4:])); weight >= 1 && weight <= 1000 {
+			info.weight = weight
+		}
+		if width := int(binary.BigEndian.Uint16(os2[6:])); width >= 1 && width <= 9 {
+			info.stretch = ttfWidths[width-1]
+		}
+		// fsSelection bit 0 is italic and bit 9 is oblique
+		selection := binary.BigEndian.Uint16(os2[62:])
+		info.italic = selection&(1<<0|1<<9) != 0
+	} else {
+		// fonts without an OS/2 table only have the style in the subfamily name
+		subfamily := strings.ToLower(ttfName(tables["name"], 2))
+		info.italic = strings.Contains(subfamily, "italic") || strings.Contains(subfamily, "oblique")
+		if strings.Contains(subfamily, "bold") {
+			info.weight = 700
+		}
+	}
+	return info, nil
+}
+
+// returns a name from a name table, preferring the Windows English name
+func ttfName(t []byte, id uint16) string {
+	if len(t) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(t[2:]))
+	storage := int(binary.BigEndian.Uint16(t[4:]))
+
+	name := ""
+	for i := 0; i < count; i++ {
+		record := 6 + i*12
+		if record+12 > len(t) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(t[record:])
+		language := binary.BigEndian.Uint16(t[record+4:])
+		if binary.BigEndian.Uint16(t[record+6:]) != id {
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(t[record+8:]))
+		offset := storage + int(binary.BigEndian.Uint16(t[record+10:]))
+		if offset+length > len(t) {
+			continue
+		}
+		value := t[offset : offset+length]
+
+		switch {
+		case platform == 3 || platform == 0:
+			// UTF-16BE
+			units := make([]uint16, len(value)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(value[j*2:])
+			}
+			name = string(utf16.Decode(units))
+			if platform == 3 && language == 0x409 {
+				return name
+			}
+		case platform == 1 && name == "":
+			// Mac Roman, which family names are ASCII in
+			name = string(value)
+		}
+	}
+	return name
+}
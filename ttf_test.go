@@ -0,0 +1,334 @@
+package pdfb
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// testSegment is a segment of a format 4 cmap subtable, mapped with delta
+// if it has no glyphs, or through the glyph array using idRangeOffset if it does
+type testSegment struct {
+	start, end, delta uint16
+	glyphs            []uint16
+}
+
+// returns a format 4 cmap subtable with the given segments, and the final
+// 0xffff segment which every subtable ends with
+func testCmap4(segments ...testSegment) []byte {
+	segments = append(segments, testSegment{start: 0xffff, end: 0xffff, delta: 1})
+	n := len(segments)
+
+	t := make([]byte, 14+n*8+2)
+	binary.BigEndian.PutUint16(t, 4)
+	binary.BigEndian.PutUint16(t[6:], uint16(n*2))
+
+	var glyphs []uint16
+	for s, segment := range segments {
+		binary.BigEndian.PutUint16(t[14+s*2:], segment.end)
+		binary.BigEndian.PutUint16(t[14+n*2+2+s*2:], segment.start)
+		binary.BigEndian.PutUint16(t[14+n*4+2+s*2:], segment.delta)
+		if segment.glyphs != nil {
+			// the offset is from the segment's idRangeOffset to its glyphs
+			offset := (n-s)*2 + len(glyphs)*2
+			binary.BigEndian.PutUint16(t[14+n*6+2+s*2:], uint16(offset))
+			glyphs = append(glyphs, segment.glyphs...)
+		}
+	}
+	for _, glyph := range glyphs {
+		t = binary.BigEndian.AppendUint16(t, glyph)
+	}
+	binary.BigEndian.PutUint16(t[2:], uint16(len(t)))
+	return t
+}
+
+func TestCmapFormat4(t *testing.T) {
+	mapped := testCmap4(
+		testSegment{start: 'A', end: 'C', delta: 10},
+		testSegment{start: 'a', end: 'e', glyphs: []uint16{5, 0, 7, 1, 9}, delta: 0xffff},
+	)
+
+	tests := []struct {
+		name    string
+		t       []byte
+		set     runeSet
+		wantErr bool
+	}{
+		{
+			name: "delta segment",
+			t:    testCmap4(testSegment{start: 'A', end: 'C', delta: 10}),
+			set:  runeSet{{'A', 'C'}},
+		},
+		{
+			name: "delta mapping to glyph 0",
+			t:    testCmap4(testSegment{start: 0x20, end: 0x20, delta: 0xffe0}),
+			set:  nil,
+		},
+		{
+			// glyphs of 0 are missing, and 1 + 0xffff wraps round to 0
+			name: "idRangeOffset segment",
+			t:    mapped,
+			set:  runeSet{{'A', 'C'}, {'a', 'a'}, {'c', 'c'}, {'e', 'e'}},
+		},
+		{
+			name: "adjacent segments",
+			t: testCmap4(
+				testSegment{start: 'a', end: 'b', delta: 1},
+				testSegment{start: 'c', end: 'd', glyphs: []uint16{3, 4}},
+			),
+			set: runeSet{{'a', 'd'}},
+		},
+		{
+			name: "glyph array cut short",
+			t:    mapped[:len(mapped)-6],
+			set:  runeSet{{'A', 'C'}, {'a', 'a'}},
+		},
+		{
+			name:    "segments cut short",
+			t:       mapped[:20],
+			wantErr: true,
+		},
+		{
+			name:    "header cut short",
+			t:       mapped[:10],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := cmapFormat4(tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cmapFormat4() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(set, tt.set) {
+				t.Errorf("cmapFormat4() = %v, want %v", set, tt.set)
+			}
+		})
+	}
+}
+
+// returns a format 12 cmap subtable with the given groups of characters
+func testCmap12(groups ...runeRange) []byte {
+	t := make([]byte, 16)
+	binary.BigEndian.PutUint16(t, 12)
+	binary.BigEndian.PutUint32(t[12:], uint32(len(groups)))
+	for i, group := range groups {
+		t = binary.BigEndian.AppendUint32(t, uint32(group.lo))
+		t = binary.BigEndian.AppendUint32(t, uint32(group.hi))
+		t = binary.BigEndian.AppendUint32(t, uint32(i+1))
+	}
+	binary.BigEndian.PutUint32(t[4:], uint32(len(t)))
+	return t
+}
+
+func TestCmapFormat12(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       []byte
+		set     runeSet
+		wantErr bool
+	}{
+		{
+			name: "groups",
+			t:    testCmap12(runeRange{'A', 'Z'}, runeRange{0x1f600, 0x1f64f}),
+			set:  runeSet{{'A', 'Z'}, {0x1f600, 0x1f64f}},
+		},
+		{
+			name: "adjacent groups",
+			t:    testCmap12(runeRange{'A', 'M'}, runeRange{'N', 'Z'}),
+			set:  runeSet{{'A', 'Z'}},
+		},
+		{
+			name: "overlapping groups",
+			t:    testCmap12(runeRange{'A', 'Z'}, runeRange{'K', 'P'}),
+			set:  runeSet{{'A', 'Z'}},
+		},
+		{
+			name: "no groups",
+			t:    testCmap12(),
+			set:  nil,
+		},
+		{
+			name:    "groups cut short",
+			t:       testCmap12(runeRange{'A', 'Z'})[:20],
+			wantErr: true,
+		},
+		{
+			name:    "header cut short",
+			t:       testCmap12()[:12],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := cmapFormat12(tt.t)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cmapFormat12() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(set, tt.set) {
+				t.Errorf("cmapFormat12() = %v, want %v", set, tt.set)
+			}
+		})
+	}
+}
+
+func TestRuneSet(t *testing.T) {
+	var set runeSet
+	for _, r := range []rune{'a', 'b', 'c', 'x', 0x4e00} {
+		set = set.add(r)
+	}
+
+	tests := []struct {
+		r   rune
+		has bool
+	}{
+		{'a', true},
+		{'c', true},
+		{'d', false},
+		{'x', true},
+		{0x4e00, true},
+		{0x4e01, false},
+		{0, false},
+	}
+
+	for _, tt := range tests {
+		if has := set.has(tt.r); has != tt.has {
+			t.Errorf("runeSet.has(%q) = %t, want %t", tt.r, has, tt.has)
+		}
+	}
+}
+
+// testName is a record of a name table
+type testName struct {
+	platform, language, id uint16
+	value                  string
+}
+
+// returns a name table with the given records, Windows and Unicode names
+// are stored as UTF-16
+func testNameTable(names ...testName) []byte {
+	t := make([]byte, 6+len(names)*12)
+	binary.BigEndian.PutUint16(t[2:], uint16(len(names)))
+	binary.BigEndian.PutUint16(t[4:], uint16(len(t)))
+
+	var storage []byte
+	for i, name := range names {
+		value := []byte(name.value)
+		if name.platform != 1 {
+			value = nil
+			for _, unit := range utf16.Encode([]rune(name.value)) {
+				value = binary.BigEndian.AppendUint16(value, unit)
+			}
+		}
+
+		record := t[6+i*12:]
+		binary.BigEndian.PutUint16(record, name.platform)
+		binary.BigEndian.PutUint16(record[4:], name.language)
+		binary.BigEndian.PutUint16(record[6:], name.id)
+		binary.BigEndian.PutUint16(record[8:], uint16(len(value)))
+		binary.BigEndian.PutUint16(record[10:], uint16(len(storage)))
+		storage = append(storage, value...)
+	}
+	return append(t, storage...)
+}
+
+func TestTtfName(t *testing.T) {
+	names := testNameTable(
+		testName{1, 0, 1, "Mac Family"},
+		testName{3, 0x407, 1, "Deutsche Familie"},
+		testName{3, 0x409, 1, "Family"},
+		testName{3, 0x409, 2, "Bold Italic"},
+		testName{0, 0, 16, "Typographic Ĉ"},
+		testName{1, 0, 4, "Mac Only"},
+	)
+
+	tests := []struct {
+		name string
+		t    []byte
+		id   uint16
+		want string
+	}{
+		{"windows english", names, 1, "Family"},
+		{"subfamily", names, 2, "Bold Italic"},
+		{"unicode platform", names, 16, "Typographic Ĉ"},
+		{"mac roman", names, 4, "Mac Only"},
+		{"missing", names, 6, ""},
+		{"other language without english", testNameTable(testName{3, 0x407, 1, "Familie"}), 1, "Familie"},
+		{"storage cut short", names[:len(names)-4], 4, ""},
+		{"records cut short", names[:20], 1, ""},
+		{"header cut short", names[:4], 1, ""},
+		{"empty", nil, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name := ttfName(tt.t, tt.id); name != tt.want {
+				t.Errorf("ttfName(%d) = %q, want %q", tt.id, name, tt.want)
+			}
+		})
+	}
+}
+
+// returns a font with the given sfnt version and tables
+func testFont(version string, tags []string, tables ...[]byte) []byte {
+	font := make([]byte, 12+len(tags)*16)
+	copy(font, version)
+	binary.BigEndian.PutUint16(font[4:], uint16(len(tags)))
+	for i, tag := range tags {
+		record := font[12+i*16:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[8:], uint32(len(font)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(tables[i])))
+		font = append(font, tables[i]...)
+	}
+	return font
+}
+
+// returns a cmap table holding one subtable for platform 3, encoding 1
+func testCmap(subtable []byte) []byte {
+	t := make([]byte, 12)
+	binary.BigEndian.PutUint16(t[2:], 1)
+	binary.BigEndian.PutUint16(t[4:], 3)
+	binary.BigEndian.PutUint16(t[6:], 1)
+	binary.BigEndian.PutUint32(t[8:], 12)
+	return append(t, subtable...)
+}
+
+func TestTtfCoverage(t *testing.T) {
+	cmap := testCmap(testCmap4(testSegment{start: 'A', end: 'C', delta: 10}))
+	font := testFont("\x00\x01\x00\x00", []string{"cmap"}, cmap)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		set     runeSet
+		wantErr bool
+	}{
+		{"truetype", font, runeSet{{'A', 'C'}}, false},
+		{"apple truetype", testFont("true", []string{"cmap"}, cmap), runeSet{{'A', 'C'}}, false},
+		{"cff outlines", testFont("OTTO", []string{"cmap"}, cmap), nil, true},
+		{"font collection", testFont("ttcf", []string{"cmap"}, cmap), nil, true},
+		{"not a font", append([]byte("GIF89a"), font[6:]...), nil, true},
+		{"no cmap", testFont("true", []string{"name"}, cmap), nil, true},
+		{"no unicode cmap", testFont("true", []string{"cmap"}, testCmap(testCmap12())[:8]), nil, true},
+		{"table cut short", font[:len(font)-2], nil, true},
+		{"directory cut short", font[:20], nil, true},
+		{"header cut short", font[:8], nil, true},
+		{"empty", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ttfCoverage(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ttfCoverage() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(set, tt.set) {
+				t.Errorf("ttfCoverage() = %v, want %v", set, tt.set)
+			}
+		})
+	}
+}
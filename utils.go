@@ -3,7 +3,9 @@ package pdfb
 import (
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -49,3 +51,79 @@ func (p *Pdfb) makeAlignStr(alignInput string) (alignStr string) {
 
 	return
 }
+
+// used to convert a hex colour string (eg. "#f00" or "#ff0000") to rgb values
+func hexToRGB(hex string) (r, g, b int) {
+	hex = strings.TrimPrefix(hex, "#")
+
+	// expand the shorthand form
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) != 6 {
+		log.Fatalf("Invalid hex colour (#%s)\n", hex)
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		log.Fatalf("Invalid hex colour (#%s)\n", hex)
+	}
+
+	return int(rgb >> 16 & 0xff), int(rgb >> 8 & 0xff), int(rgb & 0xff)
+}
+
+// used to convert rgb values to hue (0-360), saturation and lightness (0-1)
+func rgbToHSL(r, g, b int) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	l = (max + min) / 2
+
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+
+	switch max {
+	case rf:
+		h = math.Mod((gf-bf)/d+6, 6)
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+
+	return h * 60, s, l
+}
+
+// used to convert hue (0-360), saturation and lightness (0-1) to rgb values
+func hslToRGB(h, s, l float64) (r, g, b int) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var rf, gf, bf float64
+	switch {
+	case h < 60:
+		rf, gf, bf = c, x, 0
+	case h < 120:
+		rf, gf, bf = x, c, 0
+	case h < 180:
+		rf, gf, bf = 0, c, x
+	case h < 240:
+		rf, gf, bf = 0, x, c
+	case h < 300:
+		rf, gf, bf = x, 0, c
+	default:
+		rf, gf, bf = c, 0, x
+	}
+
+	return int(math.Round((rf + m) * 255)), int(math.Round((gf + m) * 255)), int(math.Round((bf + m) * 255))
+}
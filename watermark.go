@@ -0,0 +1,134 @@
+package pdfb
+
+import (
+	"log"
+	"strings"
+)
+
+// Watermark defines text or an image drawn on every page
+//
+// Angle is in degrees anticlockwise, Opacity is between 0 and 1 (default 0.15),
+// Colour is the text colour (default #888), FontSize defaults to 80,
+// and Position is either "behind" (default) or "front" of the page content.
+type Watermark struct {
+	Text     string
+	Image    string
+	Angle    float64
+	Opacity  float64
+	Colour   string
+	FontSize float64
+	Position string
+}
+
+// reports whether the watermark is drawn in front of the page content
+func (w Watermark) inFront() bool {
+	switch strings.ToLower(w.Position) {
+	case "", "b", "behind":
+		return false
+	case "f", "front":
+		return true
+	default:
+		log.Fatalf("Invalid watermark position (%s)\n", w.Position)
+	}
+	return false
+}
+
+// draws a watermark rotated around the centre of the current page
+func (p *Pdfb) drawWatermark(w Watermark) {
+	if w.Text == "" && w.Image == "" {
+		return
+	}
+
+	pageWidth, pageHeight, _ := p.pdf.PageSize(p.pdf.PageNo())
+	cx, cy := pageWidth/2, pageHeight/2
+
+	opacity := w.Opacity
+	if opacity == 0 {
+		opacity = 0.15
+	}
+
+	currentAlpha, currentBlend := p.pdf.GetAlpha()
+	p.setAlpha(opacity, "")
+	p.pdf.TransformBegin()
+	p.pdf.TransformRotate(w.Angle, cx, cy)
+
+	if w.Image != "" {
+		if !fileExists(w.Image) {
+			log.Fatalf("Watermark image could not be located (%s)\n", w.Image)
+		}
+		img := p.readImageFile(w.Image)
+		width := pageWidth * 0.6
+		height := width * float64(img.height) / float64(img.width)
+		name := p.registerImage(img, width, height)
+		p.pdf.Image(name, cx-width/2, cy-height/2, width, height, false, "", 0, "")
+	}
+
+	if w.Text != "" {
+		currentFont := p.fontCopy(p.font)
+		currentLH := p.lineHeight
+		currentR, currentG, currentB := p.pdf.GetTextColor()
+
+		fontSize := w.FontSize
+		if fontSize == 0 {
+			fontSize = 80
+		}
+		colour := w.Colour
+		if colour == "" {
+			colour = "#888"
+		}
+
+		p.SetFont(Font{Family: p.font.Family, Bold: true, Size: fontSize})
+		p.pdf.SetTextColor(hexToRGB(colour))
+
+		// centre the text on the page, the baseline sits a third of the
+		// font size below the centre to centre the capital letters
+		_, unitSize := p.pdf.GetFontSize()
+		p.textAt(cx-p.stringWidth(w.Text)/2, cy+unitSize/3, w.Text)
+
+		p.pdf.SetTextColor(currentR, currentG, currentB)
+		p.SetFont(currentFont)
+		p.SetLineHeight(currentLH)
+	}
+
+	p.pdf.TransformEnd()
+	p.pdf.SetAlpha(currentAlpha, currentBlend)
+
+	p.checkpoint("Watermark printed")
+}
+
+// Stamp is used to draw bordered text, such as "PAID" or "APPROVED",
+// on the current page, rotated by angle degrees around its centre
+// x and y are the top left corner of the stamp before rotating
+func (p *Pdfb) Stamp(text string, x, y, angle float64, hex string) {
+	currentFont := p.fontCopy(p.font)
+	currentLH := p.lineHeight
+	currentTextR, currentTextG, currentTextB := p.pdf.GetTextColor()
+	currentDrawR, currentDrawG, currentDrawB := p.pdf.GetDrawColor()
+	currentWeight := p.pdf.GetLineWidth()
+
+	p.SetFont(Font{Family: p.font.Family, Bold: true, Size: 24})
+
+	_, unitSize := p.pdf.GetFontSize()
+	padding := unitSize / 2
+	w := p.stringWidth(text) + padding*2
+	h := unitSize + padding*2
+
+	r, g, b := hexToRGB(hex)
+	p.pdf.SetTextColor(r, g, b)
+	p.pdf.SetDrawColor(r, g, b)
+	p.pdf.SetLineWidth(1)
+
+	p.pdf.TransformBegin()
+	p.pdf.TransformRotate(angle, x+w/2, y+h/2)
+	p.pdf.RoundedRect(x, y, w, h, 2, "1234", "D")
+	p.textAt(x+padding, y+padding+unitSize*0.8, text)
+	p.pdf.TransformEnd()
+
+	p.pdf.SetTextColor(currentTextR, currentTextG, currentTextB)
+	p.pdf.SetDrawColor(currentDrawR, currentDrawG, currentDrawB)
+	p.pdf.SetLineWidth(currentWeight)
+	p.SetFont(currentFont)
+	p.SetLineHeight(currentLH)
+
+	p.checkpoint("Stamp printed")
+}